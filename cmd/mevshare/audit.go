@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/duoxehyon/mev-share-go/rpc/audit"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func runAuditVerify(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("mevshare audit verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mevshare audit verify [flags] <file>")
+		flags.PrintDefaults()
+	}
+
+	expected := flags.String("head", "", "expected hash of the last entry, to detect entries removed from the end")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one audit log file")
+	}
+
+	head, err := audit.VerifyFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *expected != "" && common.HexToHash(*expected) != head.Hash {
+		return fmt.Errorf("head %s does not match the expected %s, entries are missing", head.Hash, *expected)
+	}
+
+	fmt.Fprintf(stderr, "verified %d entries, head %s\n", head.Seq, head.Hash)
+	return nil
+}
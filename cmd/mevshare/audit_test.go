@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/duoxehyon/mev-share-go/rpc/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := audit.OpenFile(path)
+	require.NoError(t, err)
+	for _, method := range []string{"mev_sendBundle", "eth_sendPrivateTransaction"} {
+		require.NoError(t, sink.Audit(rpc.AuditRecord{Method: method}))
+	}
+	head := sink.Head()
+	require.NoError(t, sink.Close())
+
+	var stderr bytes.Buffer
+	require.NoError(t, run(context.Background(), []string{"audit", "verify", "--head", head.Hash.Hex(), path}, &stderr))
+	assert.Equal(t, "verified 2 entries, head "+head.Hash.Hex()+"\n", stderr.String())
+
+	// Entries removed from the end only show against the head
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data[:bytes.IndexByte(data, '\n')+1], 0o600))
+	require.NoError(t, run(context.Background(), []string{"audit", "verify", path}, &stderr))
+	err = run(context.Background(), []string{"audit", "verify", "--head", head.Hash.Hex(), path}, &stderr)
+	assert.ErrorContains(t, err, "entries are missing")
+
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(data), "mev_sendBundle", "mev_simBundle", 1)), 0o600))
+	err = run(context.Background(), []string{"audit", "verify", path}, &stderr)
+	var chainErr *audit.ChainError
+	require.ErrorAs(t, err, &chainErr)
+	assert.Equal(t, 1, chainErr.Line)
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// errUnsupportedFormat is returned for unknown output formats
+var errUnsupportedFormat = errors.New("unsupported format")
+
+// exportConfig configures a history export
+type exportConfig struct {
+	FromBlock  uint64 // First block, the oldest available if zero
+	ToBlock    uint64 // Last block, the newest available if zero
+	Format     string // jsonl, csv or parquet
+	Output     string
+	Checkpoint string // Progress file, Output + ".checkpoint" if empty
+}
+
+// checkpoint records the progress of an export, it is removed once the export completes
+type checkpoint struct {
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+	Format    string `json:"format"`
+	Offset    uint64 `json:"offset"`  // Offset of the next page
+	Written   uint64 `json:"written"` // Events written so far
+	Size      int64  `json:"size"`    // Output size after the last page, anything past it is rewritten
+}
+
+// export pages through the event history of the block range and writes it to the output.
+// An interrupted export resumes from its checkpoint.
+func export(ctx context.Context, client sse.SSEClient, cfg exportConfig) (uint64, error) {
+	if cfg.Checkpoint == "" {
+		cfg.Checkpoint = cfg.Output + ".checkpoint"
+	}
+	if err := checkFormat(cfg.Format); err != nil {
+		return 0, err
+	}
+
+	// A parquet file is only readable once its footer is written, the pages are staged as jsonl
+	// so an interrupted export can resume, and converted once the export completes
+	output := cfg.Output
+	if cfg.Format == "parquet" {
+		cfg.Output += ".jsonl"
+	}
+
+	info, err := client.EventHistoryInfo()
+	if err != nil {
+		return 0, fmt.Errorf("history info: %w", err)
+	}
+	if cfg.FromBlock == 0 {
+		cfg.FromBlock = info.MinBlock
+	}
+	if cfg.ToBlock == 0 {
+		cfg.ToBlock = info.MaxBlock
+	}
+	if cfg.FromBlock > cfg.ToBlock {
+		return 0, fmt.Errorf("from block %d is after to block %d", cfg.FromBlock, cfg.ToBlock)
+	}
+
+	progress, resumed, err := loadCheckpoint(cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := openOutput(cfg.Output, progress.Size, resumed)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+	enc := newEncoder(cfg.Format, out)
+	if !resumed {
+		if err := enc.header(); err != nil {
+			return 0, err
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress.Written, err
+		}
+
+		page, err := client.GetEventHistory(sse.EventHistoryParams{
+			BlockStart: cfg.FromBlock,
+			BlockEnd:   cfg.ToBlock,
+			OffSet:     progress.Offset,
+			Limit:      info.MaxLimit,
+		})
+		if err != nil {
+			return progress.Written, fmt.Errorf("history at offset %d: %w", progress.Offset, err)
+		}
+
+		done := len(page) == 0 || (info.MaxLimit != 0 && uint64(len(page)) < info.MaxLimit)
+		for _, h := range page {
+			// The node orders history by block, anything past the range ends the export
+			if h.Block > cfg.ToBlock {
+				done = true
+				break
+			}
+			if h.Block < cfg.FromBlock {
+				continue
+			}
+			if err := enc.encode(h); err != nil {
+				return progress.Written, err
+			}
+			progress.Written++
+		}
+		progress.Offset += uint64(len(page))
+
+		if err := enc.flush(); err != nil {
+			return progress.Written, err
+		}
+		if err := out.Flush(); err != nil {
+			return progress.Written, err
+		}
+		if err := file.Sync(); err != nil {
+			return progress.Written, err
+		}
+		if progress.Size, err = file.Seek(0, io.SeekCurrent); err != nil {
+			return progress.Written, err
+		}
+
+		if done {
+			if cfg.Format != "parquet" {
+				return progress.Written, os.Remove(cfg.Checkpoint)
+			}
+			if err := writeParquet(cfg.Output, output); err != nil {
+				return progress.Written, err
+			}
+			// The checkpoint goes first, a staged file left by a crash is truncated by the next run
+			if err := os.Remove(cfg.Checkpoint); err != nil {
+				return progress.Written, err
+			}
+			return progress.Written, os.Remove(cfg.Output)
+		}
+		if err := saveCheckpoint(cfg.Checkpoint, progress); err != nil {
+			return progress.Written, err
+		}
+	}
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case "jsonl", "csv", "parquet":
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", errUnsupportedFormat, format)
+	}
+}
+
+// loadCheckpoint returns the saved progress for the export, or a fresh one
+func loadCheckpoint(cfg exportConfig) (checkpoint, bool, error) {
+	fresh := checkpoint{FromBlock: cfg.FromBlock, ToBlock: cfg.ToBlock, Format: cfg.Format}
+
+	data, err := os.ReadFile(cfg.Checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		return fresh, false, nil
+	}
+	if err != nil {
+		return fresh, false, err
+	}
+
+	var saved checkpoint
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fresh, false, fmt.Errorf("%s: %w", cfg.Checkpoint, err)
+	}
+	if saved.FromBlock != fresh.FromBlock || saved.ToBlock != fresh.ToBlock || saved.Format != fresh.Format {
+		return fresh, false, fmt.Errorf("%s belongs to a different export (blocks %d-%d, %s), remove it to start over",
+			cfg.Checkpoint, saved.FromBlock, saved.ToBlock, saved.Format)
+	}
+
+	return saved, true, nil
+}
+
+// saveCheckpoint replaces the checkpoint file atomically
+func saveCheckpoint(path string, progress checkpoint) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// openOutput creates the output, or reopens it at the checkpointed size when resuming
+func openOutput(path string, size int64, resumed bool) (*os.File, error) {
+	if !resumed {
+		return os.Create(path)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// encoder writes history in an output format
+type encoder interface {
+	header() error
+	encode(sse.EventHistory) error
+	flush() error
+}
+
+// newEncoder returns the encoder of the format, parquet is staged as jsonl
+func newEncoder(format string, w io.Writer) encoder {
+	if format == "csv" {
+		return &csvEncoder{w: csv.NewWriter(w)}
+	}
+	return &jsonlEncoder{enc: json.NewEncoder(w)}
+}
+
+// jsonlEncoder writes one sse.EventHistory per line, readable by sse/replay
+type jsonlEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *jsonlEncoder) header() error { return nil }
+
+func (e *jsonlEncoder) encode(h sse.EventHistory) error { return e.enc.Encode(h) }
+
+func (e *jsonlEncoder) flush() error { return nil }
+
+// csvColumns are the columns of the csv format, lists are separated by ";"
+var csvColumns = []string{
+	"block", "timestamp", "hash", "mev_gas_price", "gas_used",
+	"tx_to", "tx_function_selector", "tx_call_data", "log_address", "log_topic0",
+}
+
+// csvEncoder writes one row per event
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) header() error { return e.w.Write(csvColumns) }
+
+func (e *csvEncoder) encode(h sse.EventHistory) error { return e.w.Write(historyRecord(h)) }
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func optional(revealed bool, value string) string {
+	if !revealed {
+		return ""
+	}
+	return value
+}
+
+func bigString(b *hexutil.Big) string {
+	if b == nil {
+		return ""
+	}
+	return b.ToInt().String()
+}
+
+// historyRecord returns the csvColumns of the event
+func historyRecord(h sse.EventHistory) []string {
+	var to, selectors, callData, addresses, topics []string
+	for _, tx := range h.Hint.Txs {
+		to = append(to, optional(tx.To != [20]byte{}, tx.To.Hex()))
+		selectors = append(selectors, optional(tx.FunctionSelector != [4]byte{}, hexutil.Encode(tx.FunctionSelector[:])))
+		callData = append(callData, optional(tx.CallData != nil, hexutil.Encode(tx.CallData)))
+	}
+	for _, log := range h.Hint.Logs {
+		addresses = append(addresses, log.Address.Hex())
+		topic := ""
+		if len(log.Topics) > 0 {
+			topic = log.Topics[0].Hex()
+		}
+		topics = append(topics, topic)
+	}
+
+	return []string{
+		strconv.FormatUint(h.Block, 10),
+		strconv.FormatUint(h.Timestamp, 10),
+		h.Hint.Hash.Hex(),
+		bigString(h.Hint.MevGasPrice),
+		bigString(h.Hint.GasUsed),
+		strings.Join(to, ";"),
+		strings.Join(selectors, ";"),
+		strings.Join(callData, ";"),
+		strings.Join(addresses, ";"),
+		strings.Join(topics, ";"),
+	}
+}
+
+// parquetColumns are the csvColumns as parquet columns
+var parquetColumns = func() []parquetColumn {
+	columns := make([]parquetColumn, len(csvColumns))
+	for i, name := range csvColumns {
+		columns[i] = parquetColumn{name: name, typ: parquetByteArray, converted: parquetUTF8}
+	}
+	columns[0] = parquetColumn{name: csvColumns[0], typ: parquetInt64, converted: parquetUint64}
+	columns[1] = parquetColumn{name: csvColumns[1], typ: parquetInt64, converted: parquetUint64}
+	return columns
+}()
+
+// writeParquet converts the staged jsonl history to a parquet file at path.
+// The file is written next to path and renamed, an incomplete parquet file is never left behind.
+func writeParquet(staged, path string) error {
+	in, err := os.Open(staged)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer out.Close()
+
+	buf := bufio.NewWriter(out)
+	pw, err := newParquetWriter(buf, parquetColumns)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(in))
+	for {
+		var h sse.EventHistory
+		if err := dec.Decode(&h); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", staged, err)
+		}
+
+		values := []any{int64(h.Block), int64(h.Timestamp)}
+		for _, value := range historyRecord(h)[2:] {
+			values = append(values, value)
+		}
+		if err := pw.writeRow(values...); err != nil {
+			return err
+		}
+	}
+
+	if err := pw.close(); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
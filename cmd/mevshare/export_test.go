@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/duoxehyon/mev-share-go/mevsharetest"
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/duoxehyon/mev-share-go/sse/replay"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNode() *mevsharetest.Node {
+	var history []sse.EventHistory
+	for i := uint64(1); i <= 7; i++ {
+		history = append(history, sse.EventHistory{
+			Block:     i,
+			Timestamp: 1000 + i,
+			Hint: sse.MatchMakerEvent{
+				Hash: common.BytesToHash([]byte{byte(i)}),
+				Txs:  []sse.PendingTransaction{{To: common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")}, {FunctionSelector: [4]byte{1, 2, 3, 4}}},
+			},
+		})
+	}
+
+	return mevsharetest.NewNode(mevsharetest.WithMaxLimit(2), mevsharetest.WithHistory(history...))
+}
+
+// failingClient fails history requests after the first pages
+type failingClient struct {
+	sse.SSEClient
+	pages int
+}
+
+func (c *failingClient) GetEventHistory(params sse.EventHistoryParams) ([]sse.EventHistory, error) {
+	if c.pages == 0 {
+		return nil, errors.New("connection reset")
+	}
+	c.pages--
+	return c.SSEClient.GetEventHistory(params)
+}
+
+// pagingClient records the offsets of history requests
+type pagingClient struct {
+	sse.SSEClient
+	offsets []uint64
+}
+
+func (c *pagingClient) GetEventHistory(params sse.EventHistoryParams) ([]sse.EventHistory, error) {
+	c.offsets = append(c.offsets, params.OffSet)
+	return c.SSEClient.GetEventHistory(params)
+}
+
+func TestExport_JSONL(t *testing.T) {
+	node := testNode()
+	defer node.Close()
+
+	output := filepath.Join(t.TempDir(), "history.jsonl")
+	written, err := export(context.Background(), sse.New(node.URL), exportConfig{FromBlock: 2, ToBlock: 6, Format: "jsonl", Output: output})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), written)
+
+	entries, err := replay.Load(output)
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+	assert.Equal(t, uint64(2), entries[0].Block)
+	assert.Equal(t, uint64(6), entries[4].Block)
+
+	assert.NoFileExists(t, output+".checkpoint")
+}
+
+func TestExport_Paging(t *testing.T) {
+	node := testNode()
+	defer node.Close()
+
+	client := &pagingClient{SSEClient: sse.New(node.URL)}
+	output := filepath.Join(t.TempDir(), "history.jsonl")
+	written, err := export(context.Background(), client, exportConfig{Format: "jsonl", Output: output})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), written)
+	assert.Equal(t, []uint64{0, 2, 4, 6}, client.offsets)
+
+	// Every event is exported once
+	entries, err := replay.Load(output)
+	require.NoError(t, err)
+	require.Len(t, entries, 7)
+	for i, entry := range entries {
+		assert.Equal(t, uint64(i+1), entry.Block)
+	}
+}
+
+func TestExport_Parquet(t *testing.T) {
+	node := testNode()
+	defer node.Close()
+
+	cfg := exportConfig{FromBlock: 2, Format: "parquet", Output: filepath.Join(t.TempDir(), "history.parquet")}
+
+	// An interrupted export leaves no parquet file, it resumes from the staged pages
+	written, err := export(context.Background(), &failingClient{SSEClient: sse.New(node.URL), pages: 1}, cfg)
+	assert.ErrorContains(t, err, "connection reset")
+	assert.Equal(t, uint64(2), written)
+	assert.NoFileExists(t, cfg.Output)
+
+	written, err = export(context.Background(), sse.New(node.URL), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), written)
+	assert.NoFileExists(t, cfg.Output+".jsonl")
+	assert.NoFileExists(t, cfg.Output+".checkpoint")
+
+	names, rows := readParquet(t, cfg.Output)
+	assert.Equal(t, csvColumns, names)
+	require.Len(t, rows, 6)
+	assert.Equal(t, []any{int64(2), int64(1002), "0x0000000000000000000000000000000000000000000000000000000000000002", "", "",
+		"0x1234567890AbcdEF1234567890aBcdef12345678;", ";0x01020304", ";", "", ""}, rows[0])
+	assert.Equal(t, int64(7), rows[5][0])
+
+	// A crash after the checkpoint was removed leaves the staged file, the next export starts over
+	require.NoError(t, os.WriteFile(cfg.Output+".jsonl", []byte(`{"block":`), 0o644))
+	written, err = export(context.Background(), sse.New(node.URL), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), written)
+	assert.NoFileExists(t, cfg.Output+".jsonl")
+	_, rows = readParquet(t, cfg.Output)
+	require.Len(t, rows, 6)
+}
+
+func TestExport_Resume(t *testing.T) {
+	node := testNode()
+	defer node.Close()
+
+	cfg := exportConfig{Format: "csv", Output: filepath.Join(t.TempDir(), "history.csv")}
+
+	written, err := export(context.Background(), &failingClient{SSEClient: sse.New(node.URL), pages: 2}, cfg)
+	assert.ErrorContains(t, err, "connection reset")
+	assert.Equal(t, uint64(4), written)
+	assert.FileExists(t, cfg.Output+".checkpoint")
+
+	// A row cut off by the interruption is rewritten
+	file, err := os.OpenFile(cfg.Output, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString("5,10")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	written, err = export(context.Background(), sse.New(node.URL), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), written)
+
+	data, err := os.ReadFile(cfg.Output)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 8)
+	assert.Equal(t, strings.Join(csvColumns, ","), lines[0])
+	assert.Equal(t, "5,1005,0x0000000000000000000000000000000000000000000000000000000000000005,,,"+
+		"0x1234567890AbcdEF1234567890aBcdef12345678;,;0x01020304,;,,", lines[5])
+
+	_, err = export(context.Background(), sse.New(node.URL), exportConfig{FromBlock: 3, Format: "csv", Output: cfg.Output})
+	require.NoError(t, err)
+}
+
+func TestExport_CheckpointMismatch(t *testing.T) {
+	node := testNode()
+	defer node.Close()
+
+	cfg := exportConfig{Format: "jsonl", Output: filepath.Join(t.TempDir(), "history.jsonl")}
+	_, err := export(context.Background(), &failingClient{SSEClient: sse.New(node.URL), pages: 1}, cfg)
+	require.Error(t, err)
+
+	cfg.FromBlock = 3
+	_, err = export(context.Background(), sse.New(node.URL), cfg)
+	assert.ErrorContains(t, err, "different export")
+}
+
+func TestRun(t *testing.T) {
+	node := testNode()
+	defer node.Close()
+
+	var stderr bytes.Buffer
+	output := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, run(context.Background(), []string{"history", "export", "--url", node.URL, "--to-block", "3", "--output", output}, &stderr))
+	assert.Contains(t, stderr.String(), "exported 3 events")
+
+	err := run(context.Background(), []string{"history", "export", "--url", node.URL, "--format", "xml", "--output", output}, &stderr)
+	assert.ErrorIs(t, err, errUnsupportedFormat)
+
+	assert.Error(t, run(context.Background(), []string{"history"}, &stderr))
+}
@@ -0,0 +1,80 @@
+// Command mevshare is a command line tool for MEV-Share.
+//
+//	mevshare history export --from-block 18000000 --to-block 18001000 --format csv --output history.csv
+//	mevshare audit verify audit.log
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+)
+
+const defaultURL = "https://mev-share.flashbots.net"
+
+const usage = `usage: mevshare <command> [flags]
+
+commands:
+  history export    export event history to a file
+  audit verify      verify the hash chain of an rpc audit log
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "mevshare:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stderr io.Writer) error {
+	if len(args) >= 2 && args[0] == "history" && args[1] == "export" {
+		return runHistoryExport(ctx, args[2:], stderr)
+	}
+	if len(args) >= 2 && args[0] == "audit" && args[1] == "verify" {
+		return runAuditVerify(args[2:], stderr)
+	}
+
+	fmt.Fprint(stderr, usage)
+	return flag.ErrHelp
+}
+
+func runHistoryExport(ctx context.Context, args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("mevshare history export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var cfg exportConfig
+	url := flags.String("url", defaultURL, "matchmaker base URL")
+	flags.Uint64Var(&cfg.FromBlock, "from-block", 0, "first block, the oldest available if 0")
+	flags.Uint64Var(&cfg.ToBlock, "to-block", 0, "last block, the newest available if 0")
+	flags.StringVar(&cfg.Format, "format", "jsonl", "output format: jsonl, csv or parquet")
+	flags.StringVar(&cfg.Output, "output", "", "output file (required)")
+	flags.StringVar(&cfg.Checkpoint, "checkpoint", "", "progress file for resuming, <output>.checkpoint if empty")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if cfg.Output == "" {
+		flags.Usage()
+		return fmt.Errorf("--output is required")
+	}
+
+	written, err := export(ctx, sse.New(*url), cfg)
+	if errors.Is(err, errUnsupportedFormat) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("export stopped after %d events, run again to resume: %w", written, err)
+	}
+
+	fmt.Fprintf(stderr, "exported %d events to %s\n", written, cfg.Output)
+	return nil
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Parquet constants, see https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift
+const (
+	parquetMagic = "PAR1"
+
+	parquetInt64     int32 = 2 // Type INT64
+	parquetByteArray int32 = 6 // Type BYTE_ARRAY
+
+	parquetUTF8   int32 = 0  // ConvertedType UTF8
+	parquetUint64 int32 = 14 // ConvertedType UINT_64
+
+	parquetRequired int32 = 0 // FieldRepetitionType REQUIRED
+	parquetPlain    int32 = 0 // Encoding PLAIN
+	parquetRLE      int32 = 3 // Encoding RLE
+	parquetDataPage int32 = 0 // PageType DATA_PAGE
+	parquetNoCodec  int32 = 0 // CompressionCodec UNCOMPRESSED
+)
+
+// parquetRowGroupSize is the number of rows buffered before a row group is written
+const parquetRowGroupSize = 64 * 1024
+
+// parquetColumn is a required column of a flat parquet schema
+type parquetColumn struct {
+	name      string
+	typ       int32 // parquetInt64 or parquetByteArray
+	converted int32 // parquetUTF8 or parquetUint64
+}
+
+// parquetWriter writes rows of required int64 and string columns as a parquet file.
+// Every column of a row group is a single uncompressed PLAIN data page.
+type parquetWriter struct {
+	w       io.Writer
+	offset  int64
+	columns []parquetColumn
+
+	pages     []bytes.Buffer // PLAIN values of the buffered rows, one per column
+	rows      int64
+	total     int64
+	rowGroups []thriftStruct
+}
+
+// newParquetWriter writes the file header and returns a writer for rows of the columns
+func newParquetWriter(w io.Writer, columns []parquetColumn) (*parquetWriter, error) {
+	pw := &parquetWriter{w: w, columns: columns, pages: make([]bytes.Buffer, len(columns))}
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// writeRow buffers a row, its values are int64 or string matching the column types
+func (pw *parquetWriter) writeRow(values ...any) error {
+	if len(values) != len(pw.columns) {
+		return fmt.Errorf("parquet row has %d values, want %d", len(values), len(pw.columns))
+	}
+
+	for i, value := range values {
+		switch value.(type) {
+		case int64:
+			if pw.columns[i].typ != parquetInt64 {
+				return fmt.Errorf("parquet column %s: unexpected int64", pw.columns[i].name)
+			}
+		case string:
+			if pw.columns[i].typ != parquetByteArray {
+				return fmt.Errorf("parquet column %s: unexpected string", pw.columns[i].name)
+			}
+		default:
+			return fmt.Errorf("parquet column %s: unsupported value %T", pw.columns[i].name, value)
+		}
+	}
+
+	for i, value := range values {
+		page := &pw.pages[i]
+		switch v := value.(type) {
+		case int64:
+			_ = binary.Write(page, binary.LittleEndian, v)
+		case string:
+			_ = binary.Write(page, binary.LittleEndian, uint32(len(v)))
+			page.WriteString(v)
+		}
+	}
+
+	pw.rows++
+	if pw.rows == parquetRowGroupSize {
+		return pw.flushRowGroup()
+	}
+	return nil
+}
+
+// close writes the buffered rows and the file footer
+func (pw *parquetWriter) close() error {
+	if pw.rows > 0 {
+		if err := pw.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+
+	schema := []any{thriftStruct{
+		{4, "schema"},
+		{5, int32(len(pw.columns))},
+	}}
+	for _, column := range pw.columns {
+		schema = append(schema, thriftStruct{
+			{1, column.typ},
+			{3, parquetRequired},
+			{4, column.name},
+			{6, column.converted},
+		})
+	}
+	rowGroups := make([]any, len(pw.rowGroups))
+	for i, rowGroup := range pw.rowGroups {
+		rowGroups[i] = rowGroup
+	}
+
+	footer := thriftStruct{
+		{1, int32(1)},
+		{2, thriftList{thriftTypeStruct, schema}},
+		{3, pw.total},
+		{4, thriftList{thriftTypeStruct, rowGroups}},
+		{6, "mev-share-go"},
+	}.encode()
+
+	if err := pw.write(footer); err != nil {
+		return err
+	}
+	if err := pw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	return pw.write([]byte(parquetMagic))
+}
+
+// flushRowGroup writes a data page per column and records the row group metadata
+func (pw *parquetWriter) flushRowGroup() error {
+	var chunks []any
+	var size int64
+	for i, column := range pw.columns {
+		data := pw.pages[i].Bytes()
+		header := thriftStruct{
+			{1, parquetDataPage},
+			{2, int32(len(data))},
+			{3, int32(len(data))},
+			{5, thriftStruct{
+				{1, int32(pw.rows)},
+				{2, parquetPlain},
+				{3, parquetRLE},
+				{4, parquetRLE},
+			}},
+		}.encode()
+
+		start := pw.offset
+		if err := pw.write(header); err != nil {
+			return err
+		}
+		if err := pw.write(data); err != nil {
+			return err
+		}
+		chunkSize := int64(len(header) + len(data))
+		size += chunkSize
+
+		chunks = append(chunks, thriftStruct{
+			{2, start},
+			{3, thriftStruct{
+				{1, column.typ},
+				{2, thriftList{thriftTypeI32, []any{parquetPlain, parquetRLE}}},
+				{3, thriftList{thriftTypeBinary, []any{column.name}}},
+				{4, parquetNoCodec},
+				{5, pw.rows},
+				{6, chunkSize},
+				{7, chunkSize},
+				{9, start},
+			}},
+		})
+		pw.pages[i].Reset()
+	}
+
+	pw.rowGroups = append(pw.rowGroups, thriftStruct{
+		{1, thriftList{thriftTypeStruct, chunks}},
+		{2, size},
+		{3, pw.rows},
+	})
+	pw.total += pw.rows
+	pw.rows = 0
+
+	return nil
+}
+
+func (pw *parquetWriter) write(p []byte) error {
+	n, err := pw.w.Write(p)
+	pw.offset += int64(n)
+	return err
+}
+
+// Thrift compact protocol types, parquet metadata is encoded with it
+const (
+	thriftTypeI32    byte = 5
+	thriftTypeI64    byte = 6
+	thriftTypeBinary byte = 8
+	thriftTypeList   byte = 9
+	thriftTypeStruct byte = 12
+)
+
+// thriftField is a struct field, its value is an int32, int64, string, thriftList or thriftStruct
+type thriftField struct {
+	id    int16
+	value any
+}
+
+// thriftStruct is a struct with its fields in ascending id order
+type thriftStruct []thriftField
+
+// thriftList is a list of values of one type
+type thriftList struct {
+	elem   byte
+	values []any
+}
+
+// encode returns the struct in the thrift compact protocol
+func (s thriftStruct) encode() []byte {
+	var buf bytes.Buffer
+	s.write(&buf)
+	return buf.Bytes()
+}
+
+func (s thriftStruct) write(buf *bytes.Buffer) {
+	var last int16
+	for _, field := range s {
+		typ := thriftType(field.value)
+		if delta := field.id - last; delta > 0 && delta <= 15 {
+			buf.WriteByte(byte(delta)<<4 | typ)
+		} else {
+			buf.WriteByte(typ)
+			buf.Write(binary.AppendVarint(nil, int64(field.id)))
+		}
+		last = field.id
+		writeThriftValue(buf, field.value)
+	}
+	buf.WriteByte(0) // Stop
+}
+
+func thriftType(value any) byte {
+	switch value.(type) {
+	case int32:
+		return thriftTypeI32
+	case int64:
+		return thriftTypeI64
+	case string:
+		return thriftTypeBinary
+	case thriftList:
+		return thriftTypeList
+	case thriftStruct:
+		return thriftTypeStruct
+	}
+	panic(fmt.Sprintf("unsupported thrift value %T", value))
+}
+
+func writeThriftValue(buf *bytes.Buffer, value any) {
+	switch v := value.(type) {
+	case int32:
+		buf.Write(binary.AppendVarint(nil, int64(v)))
+	case int64:
+		buf.Write(binary.AppendVarint(nil, v))
+	case string:
+		buf.Write(binary.AppendUvarint(nil, uint64(len(v))))
+		buf.WriteString(v)
+	case thriftList:
+		if len(v.values) < 15 {
+			buf.WriteByte(byte(len(v.values))<<4 | v.elem)
+		} else {
+			buf.WriteByte(0xf0 | v.elem)
+			buf.Write(binary.AppendUvarint(nil, uint64(len(v.values))))
+		}
+		for _, elem := range v.values {
+			writeThriftValue(buf, elem)
+		}
+	case thriftStruct:
+		v.write(buf)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader decodes the thrift compact protocol, structs decode to a map of field values
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case thriftTypeI32, thriftTypeI64:
+		return r.varint()
+	case thriftTypeBinary:
+		n := int(r.uvarint())
+		r.pos += n
+		return string(r.data[r.pos-n : r.pos])
+	case thriftTypeList:
+		header := r.byte()
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		values := make([]any, n)
+		for i := range values {
+			values[i] = r.value(header & 0x0f)
+		}
+		return values
+	case thriftTypeStruct:
+		return r.structure()
+	}
+	panic("unexpected thrift type")
+}
+
+func (r *thriftReader) structure() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.varint())
+		}
+		last = id
+		fields[id] = r.value(header & 0x0f)
+	}
+}
+
+// readParquet returns the column names and rows of a parquet file written by parquetWriter
+func readParquet(t *testing.T, path string) ([]string, [][]any) {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data, []byte(parquetMagic)))
+	require.True(t, bytes.HasSuffix(data, []byte(parquetMagic)))
+
+	footerSize := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftReader{data: data[len(data)-8-footerSize : len(data)-8]}
+	meta := footer.structure()
+	require.Equal(t, footerSize, footer.pos)
+
+	var names []string
+	schema := meta[2].([]any)
+	require.Equal(t, int64(len(schema)-1), schema[0].(map[int16]any)[5])
+	for _, element := range schema[1:] {
+		names = append(names, element.(map[int16]any)[4].(string))
+	}
+
+	var rows [][]any
+	for _, rowGroup := range meta[4].([]any) {
+		group := rowGroup.(map[int16]any)
+		numRows := int(group[3].(int64))
+		groupRows := make([][]any, numRows)
+
+		for _, chunk := range group[1].([]any) {
+			column := chunk.(map[int16]any)[3].(map[int16]any)
+			r := &thriftReader{data: data, pos: int(column[9].(int64))}
+			header := r.structure()
+			require.Equal(t, int64(numRows), header[5].(map[int16]any)[1])
+
+			page := bytes.NewReader(data[r.pos : r.pos+int(header[3].(int64))])
+			for i := range groupRows {
+				if column[1] == int64(parquetInt64) {
+					var v int64
+					require.NoError(t, binary.Read(page, binary.LittleEndian, &v))
+					groupRows[i] = append(groupRows[i], v)
+					continue
+				}
+				var n uint32
+				require.NoError(t, binary.Read(page, binary.LittleEndian, &n))
+				value := make([]byte, n)
+				_, err := io.ReadFull(page, value)
+				require.NoError(t, err)
+				groupRows[i] = append(groupRows[i], string(value))
+			}
+			assert.Zero(t, page.Len())
+		}
+		rows = append(rows, groupRows...)
+	}
+	require.Equal(t, int64(len(rows)), meta[3])
+
+	return names, rows
+}
+
+func TestParquetWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rows.parquet")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	columns := []parquetColumn{
+		{name: "id", typ: parquetInt64, converted: parquetUint64},
+		{name: "name", typ: parquetByteArray, converted: parquetUTF8},
+	}
+	pw, err := newParquetWriter(file, columns)
+	require.NoError(t, err)
+
+	// Two full row groups and a partial one
+	var want [][]any
+	for i := int64(0); i < 2*parquetRowGroupSize+3; i++ {
+		name := ""
+		if i%2 == 0 {
+			name = "even"
+		}
+		require.NoError(t, pw.writeRow(i, name))
+		want = append(want, []any{i, name})
+	}
+	assert.ErrorContains(t, pw.writeRow("1", "a"), "unexpected string")
+	assert.ErrorContains(t, pw.writeRow(int64(1)), "has 1 values")
+	require.NoError(t, pw.close())
+	require.NoError(t, file.Close())
+
+	names, rows := readParquet(t, path)
+	assert.Equal(t, []string{"id", "name"}, names)
+	assert.Equal(t, want, rows)
+}
+
+// pyarrowScript prints the schema, row group count and rows of the parquet file in argv[1] as JSON
+const pyarrowScript = `
+import json, sys
+import pyarrow.parquet as pq
+
+f = pq.ParquetFile(sys.argv[1])
+t = f.read()
+print(json.dumps({
+    "schema": [[field.name, str(field.type)] for field in t.schema],
+    "rowGroups": f.metadata.num_row_groups,
+    "rows": t.to_pylist(),
+}))
+`
+
+// TestParquetWriter_PyArrow checks the file with pyarrow, a reader independent of parquetWriter.
+// It is skipped where pyarrow is not installed.
+func TestParquetWriter_PyArrow(t *testing.T) {
+	if err := exec.Command("python3", "-c", "import pyarrow.parquet").Run(); err != nil {
+		t.Skip("pyarrow is not installed")
+	}
+
+	path := filepath.Join(t.TempDir(), "rows.parquet")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	pw, err := newParquetWriter(file, []parquetColumn{
+		{name: "id", typ: parquetInt64, converted: parquetUint64},
+		{name: "name", typ: parquetByteArray, converted: parquetUTF8},
+	})
+	require.NoError(t, err)
+
+	type row struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	var want []row
+	for i := int64(0); i < parquetRowGroupSize+2; i++ {
+		name := ""
+		if i%3 == 0 {
+			name = "fizz ✓"
+		}
+		require.NoError(t, pw.writeRow(i, name))
+		want = append(want, row{ID: i, Name: name})
+	}
+	require.NoError(t, pw.close())
+	require.NoError(t, file.Close())
+
+	out, err := exec.Command("python3", "-c", pyarrowScript, path).Output()
+	require.NoError(t, err)
+
+	var got struct {
+		Schema    [][]string `json:"schema"`
+		RowGroups int        `json:"rowGroups"`
+		Rows      []row      `json:"rows"`
+	}
+	require.NoError(t, json.Unmarshal(out, &got))
+	assert.Equal(t, [][]string{{"id", "uint64"}, {"name", "string"}}, got.Schema)
+	assert.Equal(t, 2, got.RowGroups)
+	assert.Equal(t, want, got.Rows)
+}
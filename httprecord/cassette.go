@@ -0,0 +1,134 @@
+// Package httprecord records and replays the http traffic of the sse and rpc clients
+package httprecord
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// Kinds of cassette records
+const (
+	kindRequest  = "request"
+	kindResponse = "response"
+	kindChunk    = "chunk"
+	kindEnd      = "end"
+)
+
+// record is a single line of a cassette file.
+// Every interaction is written as a request, an optional response, any number
+// of response body chunks and an end record, so long lived SSE streams are
+// captured as they arrive.
+type record struct {
+	Kind   string      `json:"kind"`
+	ID     uint64      `json:"id"`
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method,omitempty"`
+	URL    string      `json:"url,omitempty"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// Chunk is a part of a response body as it was read from the connection
+type Chunk struct {
+	Time time.Time
+	Data []byte
+}
+
+// Interaction is a recorded request with its response
+type Interaction struct {
+	ID            uint64
+	Time          time.Time // When the request was sent
+	Method        string
+	URL           string
+	RequestHeader http.Header
+	RequestBody   []byte
+
+	ResponseTime   time.Time // When the response headers arrived, zero if there was no response
+	Status         int
+	ResponseHeader http.Header
+	Chunks         []Chunk
+
+	Error string // Transport or body read error, empty on success
+}
+
+// Body returns the whole response body
+func (i *Interaction) Body() []byte {
+	var body []byte
+	for _, c := range i.Chunks {
+		body = append(body, c.Data...)
+	}
+
+	return body
+}
+
+// LoadCassette reads all interactions of a cassette file ordered by request time
+func LoadCassette(path string) ([]*Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	byID := make(map[uint64]*Interaction)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("cassette line %d: %w", line, err)
+		}
+
+		i, ok := byID[r.ID]
+		if !ok {
+			if r.Kind != kindRequest {
+				return nil, fmt.Errorf("cassette line %d: %s record for unknown request %d", line, r.Kind, r.ID)
+			}
+			i = &Interaction{ID: r.ID}
+			byID[r.ID] = i
+		}
+
+		switch r.Kind {
+		case kindRequest:
+			i.Time = r.Time
+			i.Method = r.Method
+			i.URL = r.URL
+			i.RequestHeader = r.Header
+			i.RequestBody = r.Body
+		case kindResponse:
+			i.ResponseTime = r.Time
+			i.Status = r.Status
+			i.ResponseHeader = r.Header
+		case kindChunk:
+			i.Chunks = append(i.Chunks, Chunk{Time: r.Time, Data: r.Body})
+		case kindEnd:
+			i.Error = r.Error
+		default:
+			return nil, fmt.Errorf("cassette line %d: unknown record kind %q", line, r.Kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	interactions := make([]*Interaction, 0, len(byID))
+	for _, i := range byID {
+		interactions = append(interactions, i)
+	}
+	sort.Slice(interactions, func(a, b int) bool {
+		return interactions[a].ID < interactions[b].ID
+	})
+
+	return interactions, nil
+}
@@ -0,0 +1,179 @@
+package httprecord
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/mevsharetest"
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	hint := sse.MatchMakerEvent{Hash: common.HexToHash("0x01")}
+	node := mevsharetest.NewNode(
+		mevsharetest.WithScript(hint),
+		mevsharetest.WithHistory(sse.EventHistory{Block: 1, Timestamp: 2, Hint: hint}),
+	)
+	url := node.URL
+
+	key, err := crypto.HexToECDSA("0000000000000000000000000000000000000000000000000000000000000001")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	recorder, err := NewRecorder(path, nil)
+	require.NoError(t, err)
+
+	sseClient := sse.New(url, sse.WithHTTPClient(recorder.Client()))
+	rpcClient := rpc.NewClient(url, key, rpc.WithHTTPClient(recorder.Client()))
+
+	eventChan := make(chan sse.Event, 1)
+	sub, err := sseClient.Subscribe(eventChan)
+	require.NoError(t, err)
+	event := <-eventChan
+	require.NoError(t, event.Error)
+
+	history, err := sseClient.GetEventHistory(sse.EventHistoryParams{})
+	require.NoError(t, err)
+
+	sim, err := rpcClient.SimBundle(rpc.SendMevBundleArgs{}, rpc.SimMevBundleAuxArgs{})
+	require.NoError(t, err)
+
+	// Stopping closes the stream body, which writes its end record, the channel is closed once the stream is done
+	sub.Stop()
+	for range eventChan {
+	}
+	node.Close()
+	require.NoError(t, recorder.Close())
+
+	interactions, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, interactions, 3)
+	assert.Equal(t, "GET", interactions[0].Method)
+	assert.NotEmpty(t, interactions[2].RequestHeader.Get("X-Flashbots-Signature"))
+
+	replayer, err := NewReplayer(path)
+	require.NoError(t, err)
+
+	sseClient = sse.New(url, sse.WithHTTPClient(replayer.Client()))
+	rpcClient = rpc.NewClient(url, key, rpc.WithHTTPClient(replayer.Client()))
+
+	replayedChan := make(chan sse.Event, 1)
+	_, err = sseClient.Subscribe(replayedChan)
+	require.NoError(t, err)
+	replayed := <-replayedChan
+	require.NoError(t, replayed.Error)
+	assert.Equal(t, event.Data, replayed.Data)
+
+	replayedHistory, err := sseClient.GetEventHistory(sse.EventHistoryParams{})
+	require.NoError(t, err)
+	assert.Equal(t, history, replayedHistory)
+
+	replayedSim, err := rpcClient.SimBundle(rpc.SendMevBundleArgs{}, rpc.SimMevBundleAuxArgs{})
+	require.NoError(t, err)
+	assert.Equal(t, sim, replayedSim)
+
+	assert.Equal(t, 0, replayer.Remaining())
+
+	_, err = sseClient.GetEventHistory(sse.EventHistoryParams{})
+	assert.ErrorIs(t, err, ErrNoInteraction)
+}
+
+func TestReplayer_OriginalTiming(t *testing.T) {
+	start := time.Now()
+	replayer := NewReplayerFromInteractions([]*Interaction{{
+		Time:         start,
+		Method:       "GET",
+		URL:          "http://node/api/v1/history/info",
+		ResponseTime: start.Add(50 * time.Millisecond),
+		Status:       200,
+		Chunks:       []Chunk{{Time: start.Add(60 * time.Millisecond), Data: []byte(`{"count":1}`)}},
+	}}, WithOriginalTiming())
+
+	client := sse.New("http://node", sse.WithHTTPClient(replayer.Client()))
+
+	began := time.Now()
+	info, err := client.EventHistoryInfo()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.Count)
+	assert.GreaterOrEqual(t, time.Since(began), 60*time.Millisecond)
+}
+
+func TestReplayer_NoResponse(t *testing.T) {
+	replayer := NewReplayerFromInteractions([]*Interaction{{
+		ID:     7,
+		Time:   time.Now(),
+		Method: "GET",
+		URL:    "http://node/api/v1/history/info",
+	}})
+
+	_, err := replayer.Client().Get("http://node/api/v1/history/info")
+	assert.ErrorContains(t, err, "httprecord: interaction 7 has no response")
+}
+
+func TestRecorder_UnterminatedLine(t *testing.T) {
+	node := mevsharetest.NewNode()
+	defer node.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	record := func() {
+		recorder, err := NewRecorder(path, nil)
+		require.NoError(t, err)
+		resp, err := recorder.Client().Get(node.URL + "/api/v1/history/info")
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.NoError(t, recorder.Close())
+	}
+
+	// A crash in the middle of a record leaves an unterminated line
+	record()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"id":2,"kind":"requ`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	record()
+
+	interactions, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, interactions, 2)
+	assert.Equal(t, uint64(2), interactions[1].ID)
+	assert.Equal(t, http.StatusOK, interactions[1].Status)
+}
+
+func TestRecorder_Sessions(t *testing.T) {
+	node := mevsharetest.NewNode()
+	defer node.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	for _, endpoint := range []string{"/api/v1/history/info", "/api/v1/history"} {
+		recorder, err := NewRecorder(path, nil)
+		require.NoError(t, err)
+
+		resp, err := recorder.Client().Get(node.URL + endpoint)
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.NoError(t, recorder.Close())
+	}
+
+	interactions, err := LoadCassette(path)
+	require.NoError(t, err)
+	require.Len(t, interactions, 2)
+	assert.Equal(t, uint64(1), interactions[0].ID)
+	assert.Equal(t, node.URL+"/api/v1/history/info", interactions[0].URL)
+	assert.Equal(t, uint64(2), interactions[1].ID)
+	assert.Equal(t, node.URL+"/api/v1/history", interactions[1].URL)
+}
@@ -0,0 +1,200 @@
+package httprecord
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Recorder is an http.RoundTripper writing every request and response to a cassette file
+type Recorder struct {
+	next http.RoundTripper
+
+	lastID uint64
+
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewRecorder creates a Recorder appending to the cassette at path, the IDs continue after
+// the ones already recorded. An unterminated last line, left by a crash, is truncated.
+// Requests are sent with next, http.DefaultTransport if nil.
+func NewRecorder(path string, next http.RoundTripper) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	lastID, size, err := lastRecordID(file)
+	if err == nil {
+		err = file.Truncate(size)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &Recorder{
+		next:   next,
+		lastID: lastID,
+		file:   file,
+		enc:    json.NewEncoder(file),
+	}, nil
+}
+
+// lastRecordID returns the highest ID recorded in the cassette, zero if it is empty, and the size
+// of its terminated lines. An unterminated last line is skipped.
+func lastRecordID(file *os.File) (uint64, int64, error) {
+	var lastID uint64
+	var size int64
+
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return lastID, size, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		size += int64(len(data))
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		var r struct {
+			ID uint64 `json:"id"`
+		}
+		if err := json.Unmarshal(data, &r); err != nil {
+			return 0, 0, fmt.Errorf("cassette line %d: %w", line, err)
+		}
+		if r.ID > lastID {
+			lastID = r.ID
+		}
+	}
+}
+
+// Client returns an http client using the recorder as transport
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Close closes the cassette file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := atomic.AddUint64(&r.lastID, 1)
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	r.write(record{
+		Kind:   kindRequest,
+		ID:     id,
+		Time:   time.Now(),
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header,
+		Body:   body,
+	})
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		r.write(record{Kind: kindEnd, ID: id, Time: time.Now(), Error: err.Error()})
+		return nil, err
+	}
+
+	r.write(record{
+		Kind:   kindResponse,
+		ID:     id,
+		Time:   time.Now(),
+		Status: resp.StatusCode,
+		Header: resp.Header,
+	})
+
+	resp.Body = &recordingBody{ReadCloser: resp.Body, recorder: r, id: id}
+
+	return resp, nil
+}
+
+// write appends the record to the cassette
+func (r *Recorder) write(rec record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A failing cassette must not break the traffic it records
+	_ = r.enc.Encode(rec)
+}
+
+// recordingBody writes every chunk read from the response body to the cassette
+type recordingBody struct {
+	io.ReadCloser
+	recorder *Recorder
+	id       uint64
+	once     sync.Once
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.recorder.write(record{
+			Kind: kindChunk,
+			ID:   b.id,
+			Time: time.Now(),
+			Body: append([]byte(nil), p[:n]...),
+		})
+	}
+
+	if err != nil {
+		b.end(err)
+	}
+
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.end(nil)
+	return b.ReadCloser.Close()
+}
+
+// end writes the end record once, EOF is recorded as a clean end
+func (b *recordingBody) end(err error) {
+	b.once.Do(func() {
+		rec := record{Kind: kindEnd, ID: b.id, Time: time.Now()}
+		if err != nil && !errors.Is(err, io.EOF) {
+			rec.Error = err.Error()
+		}
+		b.recorder.write(rec)
+	})
+}
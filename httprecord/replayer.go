@@ -0,0 +1,220 @@
+package httprecord
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrNoInteraction is returned when a request has no unused recorded counterpart
+var ErrNoInteraction = errors.New("no recorded interaction for request")
+
+// Matcher reports whether a recorded interaction answers the request
+type Matcher func(req *http.Request, body []byte, i *Interaction) bool
+
+// DefaultMatcher matches on method, URL and request body
+func DefaultMatcher(req *http.Request, body []byte, i *Interaction) bool {
+	return req.Method == i.Method && req.URL.String() == i.URL && bytes.Equal(body, i.RequestBody)
+}
+
+// Replayer is an http.RoundTripper answering requests from a cassette.
+// Every interaction is served once, in recorded order.
+type Replayer struct {
+	matcher        Matcher
+	originalTiming bool
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// ReplayOption configures a Replayer
+type ReplayOption func(*Replayer)
+
+// WithMatcher sets how requests are matched to recorded interactions
+func WithMatcher(matcher Matcher) ReplayOption {
+	return func(r *Replayer) {
+		r.matcher = matcher
+	}
+}
+
+// WithOriginalTiming delays responses and body chunks as they were recorded
+func WithOriginalTiming() ReplayOption {
+	return func(r *Replayer) {
+		r.originalTiming = true
+	}
+}
+
+// NewReplayer creates a Replayer serving the cassette at path
+func NewReplayer(path string, opts ...ReplayOption) (*Replayer, error) {
+	interactions, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReplayerFromInteractions(interactions, opts...), nil
+}
+
+// NewReplayerFromInteractions creates a Replayer serving the given interactions
+func NewReplayerFromInteractions(interactions []*Interaction, opts ...ReplayOption) *Replayer {
+	r := &Replayer{
+		matcher:      DefaultMatcher,
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Client returns an http client using the replayer as transport
+func (r *Replayer) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Remaining returns the number of interactions that have not been served yet
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining := 0
+	for _, used := range r.used {
+		if !used {
+			remaining++
+		}
+	}
+
+	return remaining
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	i := r.take(req, body)
+	if i == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL)
+	}
+
+	if r.originalTiming && !i.ResponseTime.IsZero() {
+		if err := sleep(req, i.ResponseTime.Sub(i.Time)); err != nil {
+			return nil, err
+		}
+	}
+
+	if i.ResponseTime.IsZero() {
+		if i.Error == "" {
+			return nil, fmt.Errorf("httprecord: interaction %d has no response", i.ID)
+		}
+		return nil, errors.New(i.Error)
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode:    i.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.ResponseHeader.Clone(),
+		ContentLength: -1,
+		Request:       req,
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+
+	if !r.originalTiming {
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(i.Body()), errorReader(i.Error)))
+		return resp, nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		last := i.ResponseTime
+		for _, c := range i.Chunks {
+			if err := sleep(req, c.Time.Sub(last)); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			last = c.Time
+
+			if _, err := pw.Write(c.Data); err != nil {
+				return
+			}
+		}
+
+		if i.Error != "" {
+			pw.CloseWithError(errors.New(i.Error))
+			return
+		}
+		pw.Close()
+	}()
+	resp.Body = pr
+
+	return resp, nil
+}
+
+// take returns the first unused interaction matching the request and marks it used
+func (r *Replayer) take(req *http.Request, body []byte) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for idx, i := range r.interactions {
+		if r.used[idx] || !r.matcher(req, body, i) {
+			continue
+		}
+
+		r.used[idx] = true
+		return i
+	}
+
+	return nil
+}
+
+// sleep waits for d or until the request is canceled
+func sleep(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// errorReader returns a reader failing with the recorded body error, or an empty reader
+func errorReader(msg string) io.Reader {
+	if msg == "" {
+		return bytes.NewReader(nil)
+	}
+
+	return &failingReader{err: errors.New(msg)}
+}
+
+type failingReader struct {
+	err error
+}
+
+func (f *failingReader) Read([]byte) (int, error) {
+	return 0, f.err
+}
@@ -0,0 +1,26 @@
+// Package logging holds the logging helpers shared by the clients
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Discard is the logger of clients without one, it drops every record
+var Discard = slog.New(discardHandler{})
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// OrDiscard returns the logger, or Discard if it is nil
+func OrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger != nil {
+		return logger
+	}
+
+	return Discard
+}
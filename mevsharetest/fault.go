@@ -0,0 +1,100 @@
+package mevsharetest
+
+import (
+	"net/http"
+	"time"
+)
+
+// Endpoint identifies the part of the node a Fault applies to
+type Endpoint int
+
+const (
+	// Stream is the SSE event stream
+	Stream Endpoint = iota
+	// History is `/api/v1/history`
+	History
+	// HistoryInfo is `/api/v1/history/info`
+	HistoryInfo
+	// RPC is the JSON-RPC endpoint
+	RPC
+)
+
+// Fault describes a failure injected into the requests of one endpoint
+type Fault struct {
+	Endpoint Endpoint
+	// Method restricts an RPC fault to one JSON-RPC method, empty matches all
+	Method string
+	// Delay is waited before the request is handled
+	Delay time.Duration
+	// StatusCode is written instead of the regular response when set
+	StatusCode int
+	// Body is written instead of the regular response when set
+	Body string
+	// RPCError is returned as a JSON-RPC error when set (RPC only)
+	RPCError string
+	// Times is the number of requests affected, zero means until ClearFaults
+	Times int
+}
+
+// InjectFault adds a fault, faults are matched in the order they were added
+func (n *Node) InjectFault(f Fault) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.faults = append(n.faults, &f)
+}
+
+// ClearFaults removes all injected faults
+func (n *Node) ClearFaults() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.faults = nil
+}
+
+// takeFault returns the first fault matching the request and consumes one use of it
+func (n *Node) takeFault(endpoint Endpoint, method string) *Fault {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i, f := range n.faults {
+		if f.Endpoint != endpoint || (f.Method != "" && f.Method != method) {
+			continue
+		}
+
+		matched := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				n.faults = append(n.faults[:i], n.faults[i+1:]...)
+			}
+		}
+		return &matched
+	}
+
+	return nil
+}
+
+// apply waits for the fault delay and writes the faulty response if there is one.
+// Returns true when the response has been written.
+func (f *Fault) apply(w http.ResponseWriter) bool {
+	if f == nil {
+		return false
+	}
+
+	time.Sleep(f.Delay)
+
+	if f.StatusCode == 0 && f.Body == "" {
+		return false
+	}
+
+	status := f.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(f.Body))
+
+	return true
+}
@@ -0,0 +1,130 @@
+package mevsharetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+)
+
+// AddHistory appends events to the history served by the node
+func (n *Node) AddHistory(history ...sse.EventHistory) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.history = append(n.history, history...)
+}
+
+// handleHistoryInfo serves `/api/v1/history/info`
+func (n *Node) handleHistoryInfo(w http.ResponseWriter, r *http.Request) {
+	if n.takeFault(HistoryInfo, "").apply(w) {
+		return
+	}
+
+	n.mu.Lock()
+	info := sse.EventHistoryInfo{
+		Count:    uint64(len(n.history)),
+		MaxLimit: n.maxLimit,
+	}
+	for i, h := range n.history {
+		if i == 0 || h.Block < info.MinBlock {
+			info.MinBlock = h.Block
+		}
+		if h.Block > info.MaxBlock {
+			info.MaxBlock = h.Block
+		}
+		if i == 0 || h.Timestamp < info.MinTimestamp {
+			info.MinTimestamp = h.Timestamp
+		}
+	}
+	n.mu.Unlock()
+
+	writeJSON(w, info)
+}
+
+// parseHistoryParams reads the query parameters of the history endpoint, as the node does
+func parseHistoryParams(query url.Values) (sse.EventHistoryParams, error) {
+	var params sse.EventHistoryParams
+	for name, field := range map[string]*uint64{
+		"blockStart":     &params.BlockStart,
+		"blockEnd":       &params.BlockEnd,
+		"timestampStart": &params.TimestampStart,
+		"timestampEnd":   &params.TimestampEnd,
+		"offset":         &params.OffSet,
+		"limit":          &params.Limit,
+	} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*field = parsed
+	}
+	return params, nil
+}
+
+// handleHistory serves `GET /api/v1/history`, one page of at most maxLimit events per request
+func (n *Node) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if n.takeFault(History, "").apply(w) {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	params, err := parseHistoryParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	matched := make([]sse.EventHistory, 0)
+	for _, h := range n.history {
+		if params.BlockStart != 0 && h.Block < params.BlockStart {
+			continue
+		}
+		if params.BlockEnd != 0 && h.Block > params.BlockEnd {
+			continue
+		}
+		if params.TimestampStart != 0 && h.Timestamp < params.TimestampStart {
+			continue
+		}
+		if params.TimestampEnd != 0 && h.Timestamp > params.TimestampEnd {
+			continue
+		}
+		matched = append(matched, h)
+	}
+	limit := n.maxLimit
+	n.mu.Unlock()
+
+	if params.Limit != 0 && params.Limit < limit {
+		limit = params.Limit
+	}
+
+	if params.OffSet >= uint64(len(matched)) {
+		matched = matched[:0]
+	} else {
+		matched = matched[params.OffSet:]
+	}
+	if uint64(len(matched)) > limit {
+		matched = matched[:limit]
+	}
+
+	writeJSON(w, matched)
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
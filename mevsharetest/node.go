@@ -0,0 +1,121 @@
+// Package mevsharetest provides an in-process MEV-Share node for tests
+package mevsharetest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/duoxehyon/mev-share-go/sse"
+)
+
+// Default values used by NewNode
+const (
+	DefaultPingInterval = 100 * time.Millisecond
+	DefaultMaxLimit     = 500
+)
+
+// Node is a fake MEV-Share node listening on a loopback port.
+// Node.URL can be passed to both sse.New and rpc.NewClient.
+type Node struct {
+	URL string // Base URL of the node
+
+	server       *httptest.Server
+	pingInterval time.Duration
+	maxLimit     uint64
+	simBundle    SimBundleFunc
+
+	mu      sync.Mutex
+	script  []string
+	streams map[*stream]struct{}
+	history []sse.EventHistory
+	calls   []Call
+	faults  []*Fault
+}
+
+// SimBundleFunc produces the result of a `mev_simBundle` call
+type SimBundleFunc func(bundle rpc.SendMevBundleArgs, simOverrides rpc.SimMevBundleAuxArgs) (*rpc.SimMevBundleResponse, error)
+
+// Option configures a Node
+type Option func(*Node)
+
+// WithPingInterval sets how often `:ping` lines are written to open streams.
+// Zero disables pings.
+func WithPingInterval(interval time.Duration) Option {
+	return func(n *Node) {
+		n.pingInterval = interval
+	}
+}
+
+// WithMaxLimit sets the page size of `/api/v1/history`
+func WithMaxLimit(limit uint64) Option {
+	return func(n *Node) {
+		n.maxLimit = limit
+	}
+}
+
+// WithScript sets the events written to every stream right after it connects
+func WithScript(events ...sse.MatchMakerEvent) Option {
+	return func(n *Node) {
+		for _, event := range events {
+			n.script = append(n.script, encodeEvent(event))
+		}
+	}
+}
+
+// WithHistory sets the events served by the history endpoints
+func WithHistory(history ...sse.EventHistory) Option {
+	return func(n *Node) {
+		n.history = append(n.history, history...)
+	}
+}
+
+// WithSimBundleFunc overrides the default `mev_simBundle` result
+func WithSimBundleFunc(fn SimBundleFunc) Option {
+	return func(n *Node) {
+		n.simBundle = fn
+	}
+}
+
+// NewNode starts a new fake MEV-Share node. Close must be called to release it.
+func NewNode(opts ...Option) *Node {
+	n := &Node{
+		pingInterval: DefaultPingInterval,
+		maxLimit:     DefaultMaxLimit,
+		simBundle:    defaultSimBundle,
+		streams:      make(map[*stream]struct{}),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/history/info", n.handleHistoryInfo)
+	mux.HandleFunc("/api/v1/history", n.handleHistory)
+	mux.HandleFunc("/", n.handleRoot)
+
+	n.server = httptest.NewServer(mux)
+	n.URL = n.server.URL
+
+	return n
+}
+
+// Close drops every open stream and shuts the node down
+func (n *Node) Close() {
+	n.DropStreams()
+	n.server.Close()
+}
+
+// handleRoot serves the event stream on GET and JSON-RPC on POST
+func (n *Node) handleRoot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		n.handleStream(w, r)
+	case http.MethodPost:
+		n.handleRPC(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
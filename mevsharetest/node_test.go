@@ -0,0 +1,296 @@
+package mevsharetest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/duoxehyon/mev-share-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(b byte) sse.MatchMakerEvent {
+	return sse.MatchMakerEvent{
+		Hash: common.BytesToHash([]byte{b}),
+		Logs: []types.Log{{
+			Address: common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678"),
+			Topics:  []common.Hash{common.HexToHash("0xabcdef")},
+			Data:    []byte{0xde, 0xad, 0xbe, 0xef},
+		}},
+		Txs: []sse.PendingTransaction{{
+			To:               common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678"),
+			FunctionSelector: [4]byte{0xab, 0xcd, 0xef, 0x12},
+		}},
+	}
+}
+
+func signedTx(t *testing.T) []byte {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tx, err := gethtypes.SignNewTx(key, gethtypes.LatestSignerForChainID(big.NewInt(1)), &gethtypes.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+	})
+	require.NoError(t, err)
+
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+
+	return raw
+}
+
+func TestNode_Stream(t *testing.T) {
+	node := NewNode(WithScript(testEvent(1)), WithPingInterval(10*time.Millisecond))
+	defer node.Close()
+
+	eventChan := make(chan sse.Event, 2)
+	_, err := sse.New(node.URL).Subscribe(eventChan)
+	require.NoError(t, err)
+
+	event := <-eventChan
+	require.NoError(t, event.Error)
+	assert.Equal(t, testEvent(1), *event.Data)
+
+	node.Publish(testEvent(2))
+
+	event = <-eventChan
+	require.NoError(t, event.Error)
+	assert.Equal(t, testEvent(2), *event.Data)
+}
+
+func TestNode_Stream_DecodeMode(t *testing.T) {
+	node := NewNode(WithPingInterval(0))
+	defer node.Close()
+
+	lenient := make(chan sse.Event, 1)
+	_, err := sse.New(node.URL).Subscribe(lenient)
+	require.NoError(t, err)
+	strict := make(chan sse.Event, 1)
+	_, err = sse.New(node.URL, sse.WithDecodeMode(sse.Strict)).Subscribe(strict)
+	require.NoError(t, err)
+
+	node.PublishRaw(`{"hash":"0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef","txs":[{"callData":"0xabc"}]}`)
+
+	event := <-lenient
+	require.NoError(t, event.Error)
+	require.Len(t, event.Data.Warnings, 1)
+	assert.Equal(t, "txs[0].callData", event.Data.Warnings[0].Path)
+
+	event = <-strict
+	var fieldErr *sse.FieldError
+	assert.ErrorAs(t, event.Error, &fieldErr)
+}
+
+func TestNode_History(t *testing.T) {
+	node := NewNode(WithMaxLimit(2), WithHistory(
+		sse.EventHistory{Block: 1, Timestamp: 10, Hint: testEvent(1)},
+		sse.EventHistory{Block: 2, Timestamp: 20, Hint: testEvent(2)},
+		sse.EventHistory{Block: 3, Timestamp: 30, Hint: testEvent(3)},
+	))
+	defer node.Close()
+
+	client := sse.New(node.URL)
+
+	info, err := client.EventHistoryInfo()
+	require.NoError(t, err)
+	assert.Equal(t, sse.EventHistoryInfo{Count: 3, MinBlock: 1, MaxBlock: 3, MinTimestamp: 10, MaxLimit: 2}, *info)
+
+	page, err := client.GetEventHistory(sse.EventHistoryParams{})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, testEvent(1), page[0].Hint)
+
+	page, err = client.GetEventHistory(sse.EventHistoryParams{OffSet: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, uint64(3), page[0].Block)
+
+	// The node's query parameter API
+	resp, err := http.Get(node.URL + "/api/v1/history?timestampStart=15&limit=1&offset=1")
+	require.NoError(t, err)
+	var history []sse.EventHistory
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&history))
+	resp.Body.Close()
+	require.Len(t, history, 1)
+	assert.Equal(t, uint64(3), history[0].Block)
+
+	resp, err = http.Get(node.URL + "/api/v1/history?offset=x")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestNode_SubscribeWithBackfill(t *testing.T) {
+	node := NewNode(WithMaxLimit(1), WithHistory(
+		sse.EventHistory{Block: 1, Timestamp: 10, Hint: testEvent(1)},
+		sse.EventHistory{Block: 2, Timestamp: 20, Hint: testEvent(2)},
+	))
+	defer node.Close()
+
+	eventChan := make(chan sse.Event, 4)
+	sub, err := sse.New(node.URL).(*sse.InternalClient).SubscribeWithBackfill(context.Background(), sse.SinceTime(time.Unix(15, 0)), eventChan)
+	require.NoError(t, err)
+	defer sub.Stop()
+
+	node.Publish(testEvent(2), testEvent(3))
+
+	event := <-eventChan
+	require.NoError(t, event.Error)
+	assert.Equal(t, testEvent(2).Hash, event.Data.Hash)
+	assert.Equal(t, time.Unix(20, 0), event.NodeTime)
+
+	event = <-eventChan
+	require.NoError(t, event.Error)
+	assert.Equal(t, testEvent(3).Hash, event.Data.Hash)
+	assert.True(t, event.NodeTime.IsZero())
+}
+
+func TestNode_Redundant(t *testing.T) {
+	fast, slow := NewNode(WithPingInterval(0)), NewNode(WithPingInterval(0))
+	defer fast.Close()
+	defer slow.Close()
+
+	client := sse.NewRedundant([]string{fast.URL, slow.URL}, sse.WithReconnectDelay(5*time.Millisecond))
+	eventChan := make(chan sse.Event, 8)
+	sub, err := client.Subscribe(eventChan)
+	require.NoError(t, err)
+	defer sub.Stop()
+
+	// The redundant client connects to its upstreams in the background
+	assert.Eventually(t, func() bool { return fast.Streams() == 1 && slow.Streams() == 1 }, time.Second, 5*time.Millisecond)
+	fast.Publish(testEvent(1))
+	slow.Publish(testEvent(1))
+	assert.Equal(t, testEvent(1).Hash, (<-eventChan).Data.Hash)
+
+	// The slow node keeps delivering while the fast one reconnects
+	fast.DropStreams()
+	slow.Publish(testEvent(2))
+	assert.Equal(t, testEvent(2).Hash, (<-eventChan).Data.Hash)
+
+	assert.Eventually(t, func() bool { return fast.Streams() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return client.Stats()[1].Events == 2 }, time.Second, 5*time.Millisecond)
+	stats := client.Stats()
+	assert.Equal(t, uint64(1), stats[0].Reconnects)
+	assert.InDelta(t, 1.0, stats[0].WinRate+stats[1].WinRate, 1e-9)
+}
+
+func TestNode_Stream_Reconnect(t *testing.T) {
+	node := NewNode(WithPingInterval(0))
+	defer node.Close()
+
+	eventChan := make(chan sse.Event, 4)
+	sub, err := sse.New(node.URL, sse.WithPingTimeout(50*time.Millisecond), sse.WithReconnect(5*time.Millisecond)).Subscribe(eventChan)
+	require.NoError(t, err)
+	defer sub.Stop()
+
+	// Silent without pings, the stream stalls and is reconnected
+	assert.ErrorIs(t, (<-eventChan).Error, sse.ErrStalled)
+	assert.Eventually(t, func() bool { return sub.(*sse.Subscription).Reconnects() == 1 }, time.Second, 5*time.Millisecond)
+
+	// Ending streams are reconnected as well
+	node.DropStreams()
+	assert.Eventually(t, func() bool { return sub.(*sse.Subscription).Reconnects() >= 2 && node.Streams() == 1 }, time.Second, 5*time.Millisecond)
+	node.Publish(testEvent(1))
+
+	event := <-eventChan
+	for errors.Is(event.Error, sse.ErrStalled) {
+		event = <-eventChan
+	}
+	require.NoError(t, event.Error)
+	assert.Equal(t, testEvent(1).Hash, event.Data.Hash)
+}
+
+func TestNode_RPC(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	client := rpc.NewClient(node.URL, key)
+
+	raw := signedTx(t)
+	tx := hexutil.Bytes(raw)
+
+	res, err := client.SendBundle(rpc.SendMevBundleArgs{
+		Inclusion: rpc.Inclusion{BlockNumber: 10},
+		Body:      []rpc.MevBundleBody{{Tx: &tx}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(crypto.Keccak256(raw)), res.BundleHash)
+
+	sim, err := client.SimBundle(rpc.SendMevBundleArgs{
+		Inclusion: rpc.Inclusion{BlockNumber: 10},
+		Body:      []rpc.MevBundleBody{{Tx: &tx}},
+	}, rpc.SimMevBundleAuxArgs{})
+	require.NoError(t, err)
+	assert.True(t, sim.Success)
+	assert.Equal(t, hexutil.Uint64(9), sim.StateBlock)
+
+	hash, err := client.SendPrivateTransaction(tx.String(), &rpc.PrivateTxOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(raw), *hash)
+
+	calls := node.Calls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, "mev_sendBundle", calls[0].Method)
+	assert.Equal(t, "mev_simBundle", calls[1].Method)
+	assert.Equal(t, "eth_sendPrivateTransaction", calls[2].Method)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), calls[0].Signer)
+}
+
+func TestNode_RPC_InvalidSignature(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"mev_sendBundle","params":[]}`)
+	req, err := http.NewRequest(http.MethodPost, node.URL, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("X-Flashbots-Signature", "0x1234567890abcdef1234567890abcdef12345678:0x00")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Empty(t, node.Calls())
+}
+
+func TestNode_Faults(t *testing.T) {
+	node := NewNode()
+	defer node.Close()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	client := rpc.NewClient(node.URL, key)
+
+	node.InjectFault(Fault{Endpoint: History, StatusCode: http.StatusServiceUnavailable, Times: 1})
+	node.InjectFault(Fault{Endpoint: RPC, Method: "mev_simBundle", RPCError: "simulation failed"})
+
+	_, err = sse.New(node.URL).GetEventHistory(sse.EventHistoryParams{})
+	assert.Error(t, err)
+	_, err = sse.New(node.URL).GetEventHistory(sse.EventHistoryParams{})
+	assert.NoError(t, err)
+
+	_, err = client.SimBundle(rpc.SendMevBundleArgs{}, rpc.SimMevBundleAuxArgs{})
+	assert.ErrorContains(t, err, "simulation failed")
+
+	node.ClearFaults()
+	tx := hexutil.Bytes(signedTx(t))
+	_, err = client.SimBundle(rpc.SendMevBundleArgs{Body: []rpc.MevBundleBody{{Tx: &tx}}}, rpc.SimMevBundleAuxArgs{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,218 @@
+package mevsharetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Call is a JSON-RPC request accepted by the node
+type Call struct {
+	Method string
+	Params []json.RawMessage
+	Signer common.Address // Recovered from the `X-Flashbots-Signature` header
+	Body   []byte         // Raw request body
+}
+
+// ErrInvalidSignature is returned for requests without a valid `X-Flashbots-Signature` header
+var ErrInvalidSignature = errors.New("invalid flashbots signature")
+
+type jsonrpcRequest struct {
+	ID      interface{}       `json:"id"`
+	JSONRPC string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonrpcResponse struct {
+	ID      interface{}   `json:"id"`
+	JSONRPC string        `json:"jsonrpc"`
+	Result  interface{}   `json:"result,omitempty"`
+	Error   *jsonrpcError `json:"error,omitempty"`
+}
+
+// Calls returns every accepted JSON-RPC request in the order they were received
+func (n *Node) Calls() []Call {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]Call(nil), n.calls...)
+}
+
+// handleRPC serves `mev_sendBundle`, `mev_simBundle` and `eth_sendPrivateTransaction`
+func (n *Node) handleRPC(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req jsonrpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSON(w, jsonrpcResponse{JSONRPC: "2.0", Error: &jsonrpcError{Code: -32700, Message: err.Error()}})
+		return
+	}
+
+	fault := n.takeFault(RPC, req.Method)
+	if fault != nil && fault.RPCError != "" {
+		time.Sleep(fault.Delay)
+		writeJSON(w, jsonrpcResponse{ID: req.ID, JSONRPC: "2.0", Error: &jsonrpcError{Code: -32000, Message: fault.RPCError}})
+		return
+	}
+	if fault.apply(w) {
+		return
+	}
+
+	signer, err := verifySignature(body, r.Header.Get("X-Flashbots-Signature"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	n.mu.Lock()
+	n.calls = append(n.calls, Call{Method: req.Method, Params: req.Params, Signer: signer, Body: body})
+	n.mu.Unlock()
+
+	result, err := n.dispatch(req.Method, req.Params)
+	if err != nil {
+		writeJSON(w, jsonrpcResponse{ID: req.ID, JSONRPC: "2.0", Error: &jsonrpcError{Code: -32000, Message: err.Error()}})
+		return
+	}
+
+	writeJSON(w, jsonrpcResponse{ID: req.ID, JSONRPC: "2.0", Result: result})
+}
+
+// dispatch runs the JSON-RPC method
+func (n *Node) dispatch(method string, params []json.RawMessage) (interface{}, error) {
+	switch method {
+	case "mev_sendBundle":
+		var bundle rpc.SendMevBundleArgs
+		if err := decodeParams(params, &bundle); err != nil {
+			return nil, err
+		}
+		hash, err := bundleHash(&bundle)
+		if err != nil {
+			return nil, err
+		}
+		return rpc.SendMevBundleResponse{BundleHash: hash}, nil
+
+	case "mev_simBundle":
+		var bundle rpc.SendMevBundleArgs
+		var simOverrides rpc.SimMevBundleAuxArgs
+		if err := decodeParams(params, &bundle, &simOverrides); err != nil {
+			return nil, err
+		}
+		return n.simBundle(bundle, simOverrides)
+
+	case "eth_sendPrivateTransaction":
+		var args struct {
+			Tx hexutil.Bytes `json:"tx"`
+		}
+		if err := decodeParams(params, &args); err != nil {
+			return nil, err
+		}
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(args.Tx); err != nil {
+			return nil, err
+		}
+		return tx.Hash(), nil
+
+	default:
+		return nil, fmt.Errorf("method not found: %s", method)
+	}
+}
+
+// decodeParams decodes positional params into targets, missing trailing params are left untouched
+func decodeParams(params []json.RawMessage, targets ...interface{}) error {
+	if len(params) == 0 {
+		return errors.New("missing params")
+	}
+
+	for i, target := range targets {
+		if i >= len(params) {
+			break
+		}
+		if err := json.Unmarshal(params[i], target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bundleHash hashes the bundle body the way the node does
+func bundleHash(bundle *rpc.SendMevBundleArgs) (common.Hash, error) {
+	hashes := make([]byte, 0, len(bundle.Body)*common.HashLength)
+	for _, body := range bundle.Body {
+		switch {
+		case body.Hash != nil:
+			hashes = append(hashes, body.Hash.Bytes()...)
+		case body.Tx != nil:
+			hashes = append(hashes, crypto.Keccak256(*body.Tx)...)
+		case body.Bundle != nil:
+			inner, err := bundleHash(body.Bundle)
+			if err != nil {
+				return common.Hash{}, err
+			}
+			hashes = append(hashes, inner.Bytes()...)
+		default:
+			return common.Hash{}, errors.New("invalid bundle body")
+		}
+	}
+
+	return crypto.Keccak256Hash(hashes), nil
+}
+
+// defaultSimBundle reports every bundle as successful
+func defaultSimBundle(bundle rpc.SendMevBundleArgs, _ rpc.SimMevBundleAuxArgs) (*rpc.SimMevBundleResponse, error) {
+	res := &rpc.SimMevBundleResponse{Success: true}
+	if bundle.Inclusion.BlockNumber > 0 {
+		res.StateBlock = bundle.Inclusion.BlockNumber - 1
+	}
+
+	return res, nil
+}
+
+// verifySignature checks the `X-Flashbots-Signature` header against the body and returns the signer
+func verifySignature(body []byte, header string) (common.Address, error) {
+	parts := strings.SplitN(header, ":", 2)
+	if len(parts) != 2 || !common.IsHexAddress(parts[0]) {
+		return common.Address{}, ErrInvalidSignature
+	}
+
+	sig, err := hexutil.Decode(parts[1])
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return common.Address{}, ErrInvalidSignature
+	}
+
+	hashedBody := crypto.Keccak256Hash(body).Hex()
+	pubKey, err := crypto.SigToPub(accounts.TextHash([]byte(hashedBody)), sig)
+	if err != nil {
+		return common.Address{}, ErrInvalidSignature
+	}
+
+	signer := crypto.PubkeyToAddress(*pubKey)
+	if signer != common.HexToAddress(parts[0]) {
+		return common.Address{}, ErrInvalidSignature
+	}
+
+	return signer, nil
+}
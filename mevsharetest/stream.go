@@ -0,0 +1,149 @@
+package mevsharetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+)
+
+// stream is a single connected SSE client
+type stream struct {
+	lines chan string
+	done  chan struct{}
+}
+
+// Publish writes the event to every connected stream
+func (n *Node) Publish(events ...sse.MatchMakerEvent) {
+	for _, event := range events {
+		n.PublishRaw(encodeEvent(event))
+	}
+}
+
+// PublishRaw writes the line as is to every connected stream, e.g. malformed data
+func (n *Node) PublishRaw(line string) {
+	n.mu.Lock()
+	streams := make([]*stream, 0, len(n.streams))
+	for s := range n.streams {
+		streams = append(streams, s)
+	}
+	n.mu.Unlock()
+
+	for _, s := range streams {
+		select {
+		case s.lines <- line:
+		case <-s.done:
+		}
+	}
+}
+
+// Streams returns the number of connected streams
+func (n *Node) Streams() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.streams)
+}
+
+// DropStreams closes every connected stream
+func (n *Node) DropStreams() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for s := range n.streams {
+		close(s.done)
+		delete(n.streams, s)
+	}
+}
+
+// handleStream serves the SSE event stream
+func (n *Node) handleStream(w http.ResponseWriter, r *http.Request) {
+	if n.takeFault(Stream, "").apply(w) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Registered before the headers are sent, so events published once Subscribe returned reach the stream
+	s := &stream{
+		lines: make(chan string, 64),
+		done:  make(chan struct{}),
+	}
+
+	n.mu.Lock()
+	script := n.script
+	n.streams[s] = struct{}{}
+	n.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	defer func() {
+		n.mu.Lock()
+		if _, ok := n.streams[s]; ok {
+			close(s.done)
+			delete(n.streams, s)
+		}
+		n.mu.Unlock()
+	}()
+
+	for _, line := range script {
+		if !writeLine(w, flusher, line) {
+			return
+		}
+	}
+
+	var ping <-chan time.Time
+	if n.pingInterval > 0 {
+		ticker := time.NewTicker(n.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-ping:
+			if _, err := fmt.Fprint(w, ":ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case line := <-s.lines:
+			if !writeLine(w, flusher, line) {
+				return
+			}
+		}
+	}
+}
+
+// writeLine writes a single `data:` line, returns false if the client is gone
+func writeLine(w http.ResponseWriter, flusher http.Flusher, line string) bool {
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", line); err != nil {
+		return false
+	}
+	flusher.Flush()
+
+	return true
+}
+
+// encodeEvent encodes the event the way the node does
+func encodeEvent(event sse.MatchMakerEvent) string {
+	data, err := json.Marshal(event)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(data)
+}
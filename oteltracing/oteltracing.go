@@ -0,0 +1,85 @@
+// Package oteltracing implements the tracing.Tracer of the sse and rpc clients with OpenTelemetry
+package oteltracing
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/duoxehyon/mev-share-go/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the OpenTelemetry tracer used by default
+const InstrumentationName = "github.com/duoxehyon/mev-share-go"
+
+// Tracer adapts an OpenTelemetry tracer
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ tracing.Tracer = (*Tracer)(nil)
+
+// New creates a tracer from the provider, nil uses the global provider
+func New(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return &Tracer{tracer: provider.Tracer(InstrumentationName)}
+}
+
+// Start implements tracing.Tracer
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(convert(attrs)...))
+	return ctx, Span{span}
+}
+
+// Span adapts an OpenTelemetry span
+type Span struct {
+	span trace.Span
+}
+
+// SetAttributes implements tracing.Span
+func (s Span) SetAttributes(attrs ...tracing.Attribute) {
+	s.span.SetAttributes(convert(attrs)...)
+}
+
+// RecordError implements tracing.Span, it also sets the span status to error
+func (s Span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End implements tracing.Span
+func (s Span) End() {
+	s.span.End()
+}
+
+// convert turns the attributes into OpenTelemetry ones, unsigned integers too large for int64 become strings
+func convert(attrs []tracing.Attribute) []attribute.KeyValue {
+	converted := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		key := attribute.Key(a.Key)
+		switch v := a.Value.(type) {
+		case string:
+			converted = append(converted, key.String(v))
+		case int64:
+			converted = append(converted, key.Int64(v))
+		case uint64:
+			if v > math.MaxInt64 {
+				converted = append(converted, key.String(fmt.Sprint(v)))
+			} else {
+				converted = append(converted, key.Int64(int64(v)))
+			}
+		case bool:
+			converted = append(converted, key.Bool(v))
+		default:
+			converted = append(converted, key.String(fmt.Sprint(v)))
+		}
+	}
+	return converted
+}
@@ -0,0 +1,120 @@
+package oteltracing
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/duoxehyon/mev-share-go/tracing"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const hintHash = "0x0000000000000000000000000000000000000000000000000000000000000001"
+
+func newRecorder() (*Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return New(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))), recorder
+}
+
+// TestBundleLifecycle traces a hint through simulating and sending a bundle
+func TestBundleLifecycle(t *testing.T) {
+	tracer, recorder := newRecorder()
+
+	stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: {\"hash\":\"" + hintHash + "\"}\n\n"))
+	}))
+	defer stream.Close()
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct{ Method string }
+		_ = json.Unmarshal(body, &req)
+
+		switch req.Method {
+		case "mev_simBundle":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"success":true}}`))
+		case "mev_sendBundle":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"bundle rejected"}}`))
+		}
+	}))
+	defer node.Close()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	client := rpc.NewClient(node.URL, key, rpc.WithTracer(tracer)).(rpc.MevAPIClientContext)
+
+	eventChan := make(chan sse.Event, 1)
+	_, err = sse.New(stream.URL, sse.WithTracer(tracer)).Subscribe(eventChan)
+	require.NoError(t, err)
+
+	var event sse.Event
+	select {
+	case event = <-eventChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	require.NoError(t, event.Error)
+
+	bundle := rpc.SendMevBundleArgs{Inclusion: rpc.Inclusion{BlockNumber: hexutil.Uint64(100), MaxBlock: hexutil.Uint64(102)}}
+	_, err = client.SimBundleContext(event.Context(), bundle, rpc.SimMevBundleAuxArgs{})
+	require.NoError(t, err)
+	_, err = client.SendBundleContext(event.Context(), bundle)
+	require.Error(t, err)
+
+	// The hint span ends once the event is delivered, which may be after the calls started
+	var spans map[string]sdktrace.ReadOnlySpan
+	require.Eventually(t, func() bool {
+		spans = make(map[string]sdktrace.ReadOnlySpan)
+		for _, span := range recorder.Ended() {
+			spans[span.Name()] = span
+		}
+		return len(spans) == 3
+	}, time.Second, 10*time.Millisecond)
+	hint, sim, send := spans["mevshare.hint"], spans["mev_simBundle"], spans["mev_sendBundle"]
+
+	assert.Contains(t, hint.Attributes(), attribute.String(tracing.HintHashKey, hintHash))
+
+	for _, span := range []sdktrace.ReadOnlySpan{sim, send} {
+		assert.Equal(t, hint.SpanContext().TraceID(), span.SpanContext().TraceID())
+		assert.Equal(t, hint.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Contains(t, span.Attributes(), attribute.Int64(tracing.TargetBlockKey, 100))
+		assert.Contains(t, span.Attributes(), attribute.Int64(tracing.MaxBlockKey, 102))
+	}
+
+	assert.Contains(t, sim.Attributes(), attribute.String(tracing.MethodKey, "mev_simBundle"))
+	assert.Equal(t, codes.Unset, sim.Status().Code)
+
+	assert.Equal(t, codes.Error, send.Status().Code)
+	assert.Equal(t, "relay error response: bundle rejected", send.Status().Description)
+}
+
+func TestConvert(t *testing.T) {
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("s", "v"),
+		attribute.Int64("i", -1),
+		attribute.Int64("u", 1),
+		attribute.String("big", "18446744073709551615"),
+		attribute.Bool("b", true),
+		attribute.String("other", "1.5"),
+	}, convert([]tracing.Attribute{
+		tracing.String("s", "v"),
+		tracing.Int64("i", -1),
+		tracing.Uint64("u", 1),
+		tracing.Uint64("big", math.MaxUint64),
+		tracing.Bool("b", true),
+		{Key: "other", Value: 1.5},
+	}))
+}
@@ -0,0 +1,209 @@
+// Package prommetrics exposes the measurements of the sse and rpc clients as Prometheus collectors.
+// It is a separate package so only users enabling metrics depend on the Prometheus client.
+package prommetrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/duoxehyon/mev-share-go/rpc/policy"
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultNamespace prefixes the metric names
+const DefaultNamespace = "mevshare"
+
+// Option configures the collectors
+type Option func(*config)
+
+type config struct {
+	namespace   string
+	constLabels prometheus.Labels
+	buckets     []float64
+}
+
+// WithNamespace sets the prefix of the metric names, defaults to DefaultNamespace
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels to every metric, e.g. to tell endpoints apart
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithBuckets sets the histogram buckets, in seconds, defaults to prometheus.DefBuckets
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		namespace: DefaultNamespace,
+		buckets:   prometheus.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SSE collects the measurements of sse subscriptions, install it with sse.WithMetrics
+type SSE struct {
+	events       prometheus.Counter
+	decodeErrors prometheus.Counter
+	reconnects   prometheus.Counter
+	pingGap      prometheus.Histogram
+	backpressure prometheus.Histogram
+}
+
+var _ sse.Metrics = (*SSE)(nil)
+
+// NewSSE creates the sse collectors, register them with a prometheus.Registerer
+func NewSSE(opts ...Option) *SSE {
+	c := newConfig(opts)
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Subsystem:   "sse",
+			Name:        name,
+			Help:        help,
+			ConstLabels: c.constLabels,
+		})
+	}
+	histogram := func(name, help string) prometheus.Histogram {
+		return prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Subsystem:   "sse",
+			Name:        name,
+			Help:        help,
+			ConstLabels: c.constLabels,
+			Buckets:     c.buckets,
+		})
+	}
+
+	return &SSE{
+		events:       counter("events_received_total", "Events read from the stream."),
+		decodeErrors: counter("decode_errors_total", "Events that failed to decode."),
+		reconnects:   counter("reconnects_total", "Times the stream was reopened."),
+		pingGap:      histogram("ping_gap_seconds", "Time between consecutive pings."),
+		backpressure: histogram("backpressure_seconds", "Time delivering an event to the event channel blocked."),
+	}
+}
+
+// EventReceived implements sse.Metrics
+func (m *SSE) EventReceived() { m.events.Inc() }
+
+// DecodeError implements sse.Metrics
+func (m *SSE) DecodeError() { m.decodeErrors.Inc() }
+
+// Reconnected implements sse.Metrics
+func (m *SSE) Reconnected() { m.reconnects.Inc() }
+
+// PingGap implements sse.Metrics
+func (m *SSE) PingGap(gap time.Duration) { m.pingGap.Observe(gap.Seconds()) }
+
+// Backpressure implements sse.Metrics
+func (m *SSE) Backpressure(wait time.Duration) { m.backpressure.Observe(wait.Seconds()) }
+
+// Describe implements prometheus.Collector
+func (m *SSE) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector
+func (m *SSE) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *SSE) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.events, m.decodeErrors, m.reconnects, m.pingGap, m.backpressure}
+}
+
+// RPC collects the calls of rpc clients by method, install it with rpc.WithMetrics
+type RPC struct {
+	calls    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+var _ rpc.Metrics = (*RPC)(nil)
+
+// NewRPC creates the rpc collectors, register them with a prometheus.Registerer
+func NewRPC(opts ...Option) *RPC {
+	c := newConfig(opts)
+
+	return &RPC{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Subsystem:   "rpc",
+			Name:        "calls_total",
+			Help:        "Calls by method and result code.",
+			ConstLabels: c.constLabels,
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Subsystem:   "rpc",
+			Name:        "call_duration_seconds",
+			Help:        "Call latency by method.",
+			ConstLabels: c.constLabels,
+			Buckets:     c.buckets,
+		}, []string{"method"}),
+	}
+}
+
+// CallFinished implements rpc.Metrics
+func (m *RPC) CallFinished(method string, duration time.Duration, err error) {
+	m.calls.WithLabelValues(method, Code(err)).Inc()
+	m.duration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// Describe implements prometheus.Collector
+func (m *RPC) Describe(ch chan<- *prometheus.Desc) {
+	m.calls.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (m *RPC) Collect(ch chan<- prometheus.Metric) {
+	m.calls.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// Code is the code label of a call result: "ok", the JSON-RPC error code of an error answered by the node
+// or "relay" if it had none, "policy" for calls rejected by a rpc/policy, "not_sent" for other calls that
+// never left the client, "canceled" and "deadline_exceeded" for context errors and "transport" for everything else
+func Code(err error) string {
+	var relayErr *rpc.RelayError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &relayErr) && relayErr.Code != 0:
+		return strconv.Itoa(relayErr.Code)
+	case errors.Is(err, rpc.ErrRelayErrorResponse):
+		return "relay"
+	case errors.Is(err, policy.ErrViolation):
+		return "policy"
+	case errors.Is(err, rpc.ErrNotSent):
+		return "not_sent"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	default:
+		return "transport"
+	}
+}
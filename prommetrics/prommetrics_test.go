@@ -0,0 +1,132 @@
+package prommetrics
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/duoxehyon/mev-share-go/rpc/policy"
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSE(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(":ping\n\n:ping\n\n"))
+		w.Write([]byte("data: {\"hash\":\"0x0000000000000000000000000000000000000000000000000000000000000001\"}\n\n"))
+		w.Write([]byte("data: {\"hash\":\"0x01\"}\n\n"))
+	}))
+	defer server.Close()
+
+	metrics := NewSSE()
+	registry := registryWith(t, metrics)
+
+	eventChan := make(chan sse.Event, 2)
+	_, err := sse.New(server.URL, sse.WithMetrics(metrics), sse.WithDecodeMode(sse.Strict)).Subscribe(eventChan)
+	require.NoError(t, err)
+	for range eventChan {
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.events))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.decodeErrors))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.reconnects))
+
+	// One gap between the two pings, one delivery per event
+	metricFamilies, err := registry.Gather()
+	require.NoError(t, err)
+	counts := map[string]uint64{}
+	for _, mf := range metricFamilies {
+		if h := mf.GetMetric()[0].GetHistogram(); h != nil {
+			counts[mf.GetName()] = h.GetSampleCount()
+		}
+	}
+	assert.Equal(t, map[string]uint64{
+		"mevshare_sse_ping_gap_seconds":     1,
+		"mevshare_sse_backpressure_seconds": 2,
+	}, counts)
+
+	problems, err := testutil.GatherAndLint(registry)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestRPC(t *testing.T) {
+	responses := []string{
+		`{"jsonrpc":"2.0","id":1,"result":"ok"}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"bad bundle"}}`,
+		`{"error":"block param must be a hex int"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responses[0]))
+		responses = responses[1:]
+	}))
+	defer server.Close()
+
+	metrics := NewRPC(WithNamespace("test"))
+	client := rpc.NewClient(server.URL, newKey(t), rpc.WithMetrics(metrics))
+
+	_, err := client.CallWithSig("mev_sendBundle")
+	require.NoError(t, err)
+	_, err = client.CallWithSig("mev_sendBundle")
+	require.Error(t, err)
+	_, err = client.CallWithSig("mev_simBundle")
+	require.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.calls.WithLabelValues("mev_sendBundle", "ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.calls.WithLabelValues("mev_sendBundle", "-32000")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.calls.WithLabelValues("mev_simBundle", "relay")))
+
+	count, err := testutil.GatherAndCount(registryWith(t, metrics), "test_rpc_call_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, "ok", Code(nil))
+	assert.Equal(t, "relay", Code(fmt.Errorf("%w: invalid", rpc.ErrRelayErrorResponse)))
+	assert.Equal(t, "relay", Code(&rpc.RelayError{Message: "invalid"}))
+	assert.Equal(t, "-32602", Code(fmt.Errorf("send: %w", &rpc.RelayError{Code: -32602, Message: "invalid params"})))
+	assert.Equal(t, "policy", Code(fmt.Errorf("%w: too many bundles", policy.ErrViolation)))
+	assert.Equal(t, "not_sent", Code(fmt.Errorf("%w: simulation failed", rpc.ErrNotSent)))
+	assert.Equal(t, "canceled", Code(fmt.Errorf("post: %w", context.Canceled)))
+	assert.Equal(t, "deadline_exceeded", Code(context.DeadlineExceeded))
+	assert.Equal(t, "transport", Code(errors.New("connection refused")))
+}
+
+func TestRPC_Durations(t *testing.T) {
+	metrics := NewRPC(WithBuckets([]float64{1}))
+	metrics.CallFinished("eth_sendPrivateTransaction", 2*time.Second, nil)
+
+	expected := `
+# HELP mevshare_rpc_call_duration_seconds Call latency by method.
+# TYPE mevshare_rpc_call_duration_seconds histogram
+mevshare_rpc_call_duration_seconds_bucket{method="eth_sendPrivateTransaction",le="1"} 0
+mevshare_rpc_call_duration_seconds_bucket{method="eth_sendPrivateTransaction",le="+Inf"} 1
+mevshare_rpc_call_duration_seconds_sum{method="eth_sendPrivateTransaction"} 2
+mevshare_rpc_call_duration_seconds_count{method="eth_sendPrivateTransaction"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(metrics, strings.NewReader(expected), "mevshare_rpc_call_duration_seconds"))
+}
+
+func registryWith(t *testing.T, c prometheus.Collector) *prometheus.Registry {
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(c))
+	return registry
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return key
+}
@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// AuditRecord is a signed request sent by a client and its outcome
+type AuditRecord struct {
+	Time        time.Time       `json:"time"`
+	Endpoint    string          `json:"endpoint"`
+	Method      string          `json:"method"`
+	Body        json.RawMessage `json:"body"`      // The exact signed request body
+	Signature   string          `json:"signature"` // The X-Flashbots-Signature header
+	TargetBlock uint64          `json:"targetBlock,omitempty"`
+	MaxBlock    uint64          `json:"maxBlock,omitempty"`
+	Response    json.RawMessage `json:"response,omitempty"` // The response body, if one was received
+	Error       string          `json:"error,omitempty"`
+}
+
+// AuditSink records every signed request, see the audit package for a tamper-evident file
+type AuditSink interface {
+	Audit(record AuditRecord) error
+}
+
+// WithAuditSink records every signed request once it completed. The request has been sent by then,
+// so a failing sink does not fail the call, it is logged as an error.
+func WithAuditSink(sink AuditSink) Option {
+	return func(c *Client) {
+		c.audit = sink
+	}
+}
+
+// targetBlocks reads the target blocks of a bundle or the max block of a private transaction from a request body
+func targetBlocks(body []byte) (target, max uint64) {
+	var req struct {
+		Params []struct {
+			Inclusion struct {
+				Block    hexutil.Uint64 `json:"block"`
+				MaxBlock hexutil.Uint64 `json:"maxBlock"`
+			} `json:"inclusion"`
+			MaxBlockNumber hexutil.Uint64 `json:"maxBlockNumber"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || len(req.Params) == 0 {
+		return 0, 0
+	}
+
+	params := req.Params[0]
+	if params.Inclusion.Block != 0 {
+		return uint64(params.Inclusion.Block), uint64(params.Inclusion.MaxBlock)
+	}
+	return 0, uint64(params.MaxBlockNumber)
+}
+
+// auditCall records a signed request with the client's audit sink, if any
+func (c *Client) auditCall(method string, body []byte, signature string, response []byte, err error) {
+	if c.audit == nil {
+		return
+	}
+
+	record := AuditRecord{
+		Time:      time.Now(),
+		Endpoint:  c.baseURL,
+		Method:    method,
+		Body:      body,
+		Signature: signature,
+	}
+	record.TargetBlock, record.MaxBlock = targetBlocks(body)
+	if json.Valid(response) {
+		record.Response = response
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if err := c.audit.Audit(record); err != nil {
+		c.log().Error("audit failed", "method", method, "err", err)
+	}
+}
@@ -0,0 +1,231 @@
+// Package audit keeps a tamper-evident log of the requests signed by an rpc.Client.
+//
+// The log is a file of JSON entries, one per line. Every entry holds the hash of the previous one,
+// so changing, reordering or removing an entry breaks the chain from there on, see Verify.
+// Removing entries from the end is only detected against a head hash kept elsewhere.
+package audit
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrClosed is returned by a closed FileSink
+var ErrClosed = errors.New("audit log closed")
+
+// Entry is a line of the audit log
+type Entry struct {
+	Seq    uint64          `json:"seq"`  // Position in the log, from 1
+	Prev   common.Hash     `json:"prev"` // Hash of the previous entry, zero for the first
+	Hash   common.Hash     `json:"hash"` // Hash of this entry, see EntryHash
+	Record json.RawMessage `json:"record"`
+}
+
+// EntryHash chains an entry: keccak256(prev || seq as 8 big endian bytes || record)
+func EntryHash(prev common.Hash, seq uint64, record []byte) common.Hash {
+	var seqBytes [8]byte
+	binary.BigEndian.PutUint64(seqBytes[:], seq)
+	return crypto.Keccak256Hash(prev[:], seqBytes[:], record)
+}
+
+// ChainError is a broken link of the audit log
+type ChainError struct {
+	Line   int // Line of the entry, from 1
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit log line %d: %s", e.Line, e.Reason)
+}
+
+// Head is the end of a verified audit log
+type Head struct {
+	Seq  uint64      // Number of entries
+	Hash common.Hash // Hash of the last entry, zero if there is none
+}
+
+// truncatedEntry is the ChainError reason of a last entry without its newline
+const truncatedEntry = "truncated entry"
+
+// Verify checks the chain of an audit log and returns its head.
+// The first broken link is returned as a *ChainError.
+func Verify(r io.Reader) (Head, error) {
+	head, _, err := verify(r)
+	return head, err
+}
+
+// verify is Verify, it also returns the size of the verified entries
+func verify(r io.Reader) (Head, int64, error) {
+	var head Head
+	var size int64
+
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(data) == 0 {
+			return head, size, nil
+		}
+		if err != nil && err != io.EOF {
+			return head, size, err
+		}
+		if err == io.EOF {
+			return head, size, &ChainError{Line: line, Reason: truncatedEntry}
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return head, size, &ChainError{Line: line, Reason: fmt.Sprintf("malformed entry: %v", err)}
+		}
+		if entry.Seq != head.Seq+1 {
+			return head, size, &ChainError{Line: line, Reason: fmt.Sprintf("sequence %d follows %d", entry.Seq, head.Seq)}
+		}
+		if entry.Prev != head.Hash {
+			return head, size, &ChainError{Line: line, Reason: fmt.Sprintf("previous hash %s does not match %s", entry.Prev, head.Hash)}
+		}
+		if hash := EntryHash(entry.Prev, entry.Seq, entry.Record); entry.Hash != hash {
+			return head, size, &ChainError{Line: line, Reason: fmt.Sprintf("hash %s does not match the entry, expected %s", entry.Hash, hash)}
+		}
+
+		head = Head{Seq: entry.Seq, Hash: entry.Hash}
+		size += int64(len(data))
+	}
+}
+
+// VerifyFile verifies the audit log at path, see Verify
+func VerifyFile(path string) (Head, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Head{}, err
+	}
+	defer file.Close()
+
+	return Verify(file)
+}
+
+// FileSink appends the records of an rpc.Client to a hash-chained audit log, every entry is synced to disk
+type FileSink struct {
+	mu     sync.Mutex
+	file   logFile
+	head   Head
+	size   int64 // Size of the entries up to head, anything past it is a partial entry
+	dirty  bool  // A failed write left a partial entry that is not truncated yet
+	closed bool
+}
+
+// logFile is the file of a FileSink
+type logFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+var _ rpc.AuditSink = (*FileSink)(nil)
+
+// OpenFile opens or creates the audit log at path. An existing log is verified first
+// and not appended to if its chain is broken. A partial last entry, left by a crash or a failed write,
+// is truncated back to the last verified entry.
+func OpenFile(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	head, size, err := verify(file)
+	var chainErr *ChainError
+	if errors.As(err, &chainErr) && chainErr.Reason == truncatedEntry {
+		err = file.Truncate(size)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &FileSink{file: file, head: head, size: size}, nil
+}
+
+// Audit implements rpc.AuditSink
+func (s *FileSink) Audit(record rpc.AuditRecord) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if err := s.truncate(); err != nil {
+		return err
+	}
+
+	entry := Entry{
+		Seq:    s.head.Seq + 1,
+		Prev:   s.head.Hash,
+		Record: encoded,
+	}
+	entry.Hash = EntryHash(entry.Prev, entry.Seq, entry.Record)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := s.file.Write(line); err != nil {
+		s.dirty = true
+		return errors.Join(err, s.truncate())
+	}
+	if err := s.file.Sync(); err != nil {
+		s.dirty = true
+		return errors.Join(err, s.truncate())
+	}
+
+	s.head = Head{Seq: entry.Seq, Hash: entry.Hash}
+	s.size += int64(len(line))
+	return nil
+}
+
+// truncate removes the partial entry of a failed write, the next write retries if it fails
+func (s *FileSink) truncate() error {
+	if !s.dirty {
+		return nil
+	}
+	if err := s.file.Truncate(s.size); err != nil {
+		return err
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// Head returns the last entry written, keep its hash elsewhere to detect entries removed from the end
+func (s *FileSink) Head() Head {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.head
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.file.Close()
+}
@@ -0,0 +1,203 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0000000000000000000000000000000000000000000000000000000000000001"}}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := OpenFile(path)
+	require.NoError(t, err)
+
+	client := rpc.NewClient(server.URL, key, rpc.WithAuditSink(sink))
+	_, err = client.SendBundle(rpc.SendMevBundleArgs{Inclusion: rpc.Inclusion{BlockNumber: 100, MaxBlock: 102}})
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+	assert.ErrorIs(t, sink.Audit(rpc.AuditRecord{}), ErrClosed)
+
+	// Reopening continues the chain
+	sink, err = OpenFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), sink.Head().Seq)
+	_, err = rpc.NewClient("http://127.0.0.1:0", key, rpc.WithAuditSink(sink)).SendPrivateTransaction("0x01", &rpc.PrivateTxOptions{MaxBlockNumber: 200})
+	require.Error(t, err)
+	head := sink.Head()
+	require.NoError(t, sink.Close())
+
+	verified, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, head, verified)
+	assert.Equal(t, uint64(2), verified.Seq)
+
+	records := readRecords(t, path)
+	require.Len(t, records, 2)
+
+	bundle := records[0]
+	assert.Equal(t, server.URL, bundle.Endpoint)
+	assert.Equal(t, "mev_sendBundle", bundle.Method)
+	assert.Equal(t, uint64(100), bundle.TargetBlock)
+	assert.Equal(t, uint64(102), bundle.MaxBlock)
+	assert.Contains(t, string(bundle.Response), "bundleHash")
+	assert.Empty(t, bundle.Error)
+
+	// The stored body is the one that was signed
+	sig, err := hexutil.Decode(bundle.Signature[43:])
+	require.NoError(t, err)
+	pubKey, err := crypto.SigToPub(accounts.TextHash([]byte(crypto.Keccak256Hash(bundle.Body).Hex())), sig)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), crypto.PubkeyToAddress(*pubKey))
+
+	tx := records[1]
+	assert.Equal(t, "eth_sendPrivateTransaction", tx.Method)
+	assert.Equal(t, uint64(200), tx.MaxBlock)
+	assert.Empty(t, tx.Response)
+	assert.NotEmpty(t, tx.Error)
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	var log bytes.Buffer
+	for i := 0; i < 3; i++ {
+		log.WriteString(entryLine(t, uint64(i+1), headOf(t, log.String()), `{"method":"mev_sendBundle"}`))
+	}
+	valid := log.String()
+	head, err := Verify(strings.NewReader(valid))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), head.Seq)
+
+	lines := strings.SplitAfter(valid, "\n")
+
+	for name, tc := range map[string]struct {
+		log  string
+		line int
+	}{
+		"changed":   {strings.Replace(valid, "mev_sendBundle", "mev_simBundle", 1), 1},
+		"removed":   {lines[0] + lines[2], 2},
+		"reordered": {lines[1] + lines[0] + lines[2], 1},
+		"truncated": {valid[:len(valid)-10], 3},
+		"malformed": {lines[0] + "{\n", 2},
+	} {
+		_, err := Verify(strings.NewReader(tc.log))
+		var chainErr *ChainError
+		require.ErrorAs(t, err, &chainErr, name)
+		assert.Equal(t, tc.line, chainErr.Line, name)
+	}
+
+	// A broken log is not appended to
+	path := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(t, os.WriteFile(path, []byte(lines[1]), 0o600))
+	_, err = OpenFile(path)
+	assert.Error(t, err)
+}
+
+func TestOpenFile_PartialEntry(t *testing.T) {
+	valid := entryLine(t, 1, Head{}, `{"method":"mev_sendBundle"}`)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(t, os.WriteFile(path, []byte(valid+`{"seq":2,"prev":`), 0o600))
+
+	// The partial entry of a crash is truncated away
+	sink, err := OpenFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, headOf(t, valid), sink.Head())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, valid, string(data))
+
+	require.NoError(t, sink.Audit(rpc.AuditRecord{Method: "mev_simBundle"}))
+	require.NoError(t, sink.Close())
+
+	head, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), head.Seq)
+}
+
+// failingFile writes half of the next write and fails it
+type failingFile struct {
+	*os.File
+	fail bool
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	if !f.fail {
+		return f.File.Write(p)
+	}
+	f.fail = false
+	n, _ := f.File.Write(p[:len(p)/2])
+	return n, errors.New("no space left on device")
+}
+
+func TestFileSink_WriteError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := OpenFile(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Audit(rpc.AuditRecord{Method: "mev_sendBundle"}))
+	head := sink.Head()
+
+	file := &failingFile{File: sink.file.(*os.File), fail: true}
+	sink.file = file
+	assert.ErrorContains(t, sink.Audit(rpc.AuditRecord{Method: "mev_simBundle"}), "no space left on device")
+	assert.Equal(t, head, sink.Head())
+
+	// The partial entry is truncated, the log stays valid and can be appended to
+	verified, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, head, verified)
+
+	require.NoError(t, sink.Audit(rpc.AuditRecord{Method: "mev_simBundle"}))
+	require.NoError(t, sink.Close())
+
+	records := readRecords(t, path)
+	require.Len(t, records, 2)
+	assert.Equal(t, "mev_simBundle", records[1].Method)
+}
+
+func entryLine(t *testing.T, seq uint64, prev Head, record string) string {
+	entry := Entry{Seq: seq, Prev: prev.Hash, Record: []byte(record)}
+	entry.Hash = EntryHash(entry.Prev, entry.Seq, entry.Record)
+	line, err := json.Marshal(entry)
+	require.NoError(t, err)
+	return string(line) + "\n"
+}
+
+func headOf(t *testing.T, log string) Head {
+	head, err := Verify(strings.NewReader(log))
+	require.NoError(t, err)
+	return head
+}
+
+func readRecords(t *testing.T, path string) []rpc.AuditRecord {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var records []rpc.AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry Entry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		var record rpc.AuditRecord
+		require.NoError(t, json.Unmarshal(entry.Record, &record))
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditFunc func(AuditRecord) error
+
+func (f auditFunc) Audit(record AuditRecord) error { return f(record) }
+
+func TestTargetBlocks(t *testing.T) {
+	for body, expected := range map[string][2]uint64{
+		`{"params":[{"inclusion":{"block":"0x64","maxBlock":"0x66"}}]}`: {100, 102},
+		`{"params":[{"tx":"0x01","maxBlockNumber":"0xc8"}]}`:            {0, 200},
+		`{"params":["0x1"]}`: {0, 0},
+		`{`:                  {0, 0},
+	} {
+		target, max := targetBlocks([]byte(body))
+		assert.Equal(t, expected, [2]uint64{target, max}, body)
+	}
+}
+
+func TestClient_AuditSinkFailure(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	var records []AuditRecord
+	client := NewClient("http://127.0.0.1:0", key,
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+		WithAuditSink(auditFunc(func(record AuditRecord) error {
+			records = append(records, record)
+			return errors.New("disk full")
+		})),
+	)
+
+	_, err = client.CallWithSig("mev_test", "0x1")
+	assert.NotContains(t, err.Error(), "disk full")
+	require.Len(t, records, 1)
+	assert.JSONEq(t, `{"id":1,"jsonrpc":"2.0","method":"mev_test","params":["0x1"]}`, string(records[0].Body))
+	assert.Equal(t, err.Error(), records[0].Error)
+	assert.Contains(t, buf.String(), `msg="audit failed" method=mev_test err="disk full"`)
+}
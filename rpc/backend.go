@@ -2,6 +2,8 @@
 package rpc
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/flashbots/mev-share-node/mevshare"
 )
@@ -17,3 +19,12 @@ type MevAPIClient interface {
 	// Send private transaction with hints
 	SendPrivateTransaction(signedRawTx string, options *PrivateTxOptions) (*common.Hash, error)
 }
+
+// MevAPIClientContext are the MevAPIClient requests with a context, traced as children of the span in ctx.
+// The client returned by NewClient implements it.
+type MevAPIClientContext interface {
+	CallWithSigContext(ctx context.Context, method string, params ...interface{}) ([]byte, error)
+	SendBundleContext(ctx context.Context, bundle mevshare.SendMevBundleArgs) (*mevshare.SendMevBundleResponse, error)
+	SimBundleContext(ctx context.Context, bundle mevshare.SendMevBundleArgs, simOverrides mevshare.SimMevBundleAuxArgs) (*mevshare.SimMevBundleResponse, error)
+	SendPrivateTransactionContext(ctx context.Context, signedRawTx string, options *PrivateTxOptions) (*common.Hash, error)
+}
@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/metachris/flashbotsrpc"
+)
+
+// ErrRelayErrorResponse means the node answered with an error, it is the same error as flashbotsrpc's
+var ErrRelayErrorResponse = flashbotsrpc.ErrRelayErrorResponse
+
+// ErrNotSent means a call failed before its request left the client, e.g. it could not be encoded or signed,
+// or a dry run did not send it
+var ErrNotSent = errors.New("request not sent")
+
+// RelayError is an error answered by the node, errors.Is(err, ErrRelayErrorResponse) holds for it
+type RelayError struct {
+	Code    int // The JSON-RPC error code, zero if the node answered with a plain error message
+	Message string
+}
+
+func (e *RelayError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrRelayErrorResponse, e.Message)
+}
+
+// Unwrap returns ErrRelayErrorResponse
+func (e *RelayError) Unwrap() error {
+	return ErrRelayErrorResponse
+}
+
+// relayError returns err as a RelayError parsed from the response, flashbotsrpc only keeps the message
+func relayError(response []byte, err error) error {
+	if !errors.Is(err, ErrRelayErrorResponse) {
+		return err
+	}
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(response, &resp) != nil || len(resp.Error) == 0 {
+		return err
+	}
+	var message string
+	if json.Unmarshal(resp.Error, &message) == nil {
+		return &RelayError{Message: message}
+	}
+	var rpcErr struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(resp.Error, &rpcErr) != nil {
+		return err
+	}
+	return &RelayError{Code: rpcErr.Code, Message: rpcErr.Message}
+}
+
+// exchange is the http client of a single call, it sends the request signed by flashbotsrpc with the
+// call's context and keeps what was sent and received for the logs and the audit sink
+type exchange struct {
+	ctx    context.Context
+	client *http.Client
+	logger *slog.Logger
+
+	body      []byte // The signed request body, nil if nothing was sent
+	signature string
+	response  []byte // The response body, if one was received
+}
+
+// Do implements flashbotsrpc's http client
+func (e *exchange) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	e.body = body
+	e.signature = req.Header.Get("X-Flashbots-Signature")
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	e.logger.Debug("rpc request", "size", len(body), "signer", signer(e.signature), "body", redactedBody(body))
+
+	start := time.Now()
+	resp, err := e.client.Do(req.WithContext(e.ctx))
+	if err != nil {
+		e.logger.Warn("rpc request failed", "err", err, "duration", time.Since(start))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		e.logger.Warn("rpc response failed", "status", resp.StatusCode, "err", err, "duration", time.Since(start))
+		return nil, err
+	}
+	e.response = data
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	e.logger.Debug("rpc response", "status", resp.StatusCode, "size", len(data), "duration", time.Since(start))
+
+	return resp, nil
+}
+
+// Post implements flashbotsrpc's http client, it is not used for signed calls
+func (e *exchange) Post(url string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(e.ctx, "POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return e.client.Do(req)
+}
+
+// callWithSig sends the request with flashbotsrpc's CallWithFlashbotsSignature and returns the raw result
+func (c *Client) callWithSig(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+	flashbotsClient := flashbotsrpc.New(c.baseURL)
+	ex := &exchange{
+		ctx:    ctx,
+		client: c.httpClient,
+		logger: c.log().With("method", method),
+	}
+	if ex.client == nil {
+		ex.client = &http.Client{Timeout: flashbotsClient.Timeout}
+	}
+	// New replaces the http client after applying its options, so the option is applied afterwards
+	flashbotsrpc.WithHttpClient(ex)(flashbotsClient)
+
+	res, err := flashbotsClient.CallWithFlashbotsSignature(method, c.privKey, params...)
+	if ex.body == nil {
+		if err != nil {
+			ex.logger.Error("rpc request not sent", "err", err)
+			err = fmt.Errorf("%w: %w", ErrNotSent, err)
+		}
+		return res, err
+	}
+	if err != nil && ex.response != nil {
+		err = relayError(ex.response, err)
+		ex.logger.Warn("rpc error", "err", err)
+	}
+
+	c.auditCall(method, ex.body, ex.signature, ex.response, err)
+	return res, err
+}
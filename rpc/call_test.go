@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CallWithSig(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"id":1,"jsonrpc":"2.0","method":"mev_test","params":["0x1"]}`, string(body))
+
+		signature := r.Header.Get("X-Flashbots-Signature")
+		sig, err := hexutil.Decode(signature[43:])
+		assert.NoError(t, err)
+		pubKey, err := crypto.SigToPub(accounts.TextHash([]byte(crypto.Keccak256Hash(body).Hex())), sig)
+		assert.NoError(t, err)
+		assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey).Hex(), signature[:42])
+		assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), crypto.PubkeyToAddress(*pubKey))
+
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+	}))
+	defer server.Close()
+
+	res, err := NewClient(server.URL, key).CallWithSig("mev_test", "0x1")
+	require.NoError(t, err)
+	assert.Equal(t, `"ok"`, string(res))
+}
+
+func TestClient_CallWithSig_Errors(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	for body, code := range map[string]int{
+		`{"error":"block param must be a hex int"}`:                                                  0,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"block param must be a hex int"}}`: -32000,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}))
+
+		_, err := NewClient(server.URL, key).CallWithSig("mev_test")
+		server.Close()
+
+		assert.True(t, errors.Is(err, ErrRelayErrorResponse))
+		assert.False(t, errors.Is(err, ErrNotSent))
+		assert.EqualError(t, err, "relay error response: block param must be a hex int")
+		var relayErr *RelayError
+		require.True(t, errors.As(err, &relayErr))
+		assert.Equal(t, code, relayErr.Code)
+	}
+
+	// A request that cannot be encoded is not sent
+	sent := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = true
+	}))
+	defer server.Close()
+
+	_, err = NewClient(server.URL, key).CallWithSig("mev_test", make(chan int))
+	assert.ErrorIs(t, err, ErrNotSent)
+	assert.False(t, sent)
+}
+
+func TestClient_CallWithSigContext(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = NewClient(server.URL, key).(MevAPIClientContext).CallWithSigContext(ctx, "mev_test")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClient_WithHTTPClient(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+	}))
+	defer server.Close()
+
+	transport := new(countingTransport)
+	_, err = NewClient(server.URL, key, WithHTTPClient(&http.Client{Transport: transport})).CallWithSig("mev_test")
+	require.NoError(t, err)
+	assert.Equal(t, 1, transport.requests)
+}
@@ -1,39 +1,116 @@
 package rpc
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"encoding/json"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/duoxehyon/mev-share-go/tracing"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/flashbots/mev-share-node/mevshare"
-	"github.com/metachris/flashbotsrpc"
 )
 
 // RPC client
 type Client struct {
-	httpClient      *http.Client
-	flashbotsClient *flashbotsrpc.FlashbotsRPC
-	privKey         *ecdsa.PrivateKey
-	baseURL         string
+	httpClient *http.Client
+	privKey    *ecdsa.PrivateKey
+	baseURL    string
+	metrics    Metrics
+	tracer     tracing.Tracer
+	logger     *slog.Logger
+
+	interceptors []Interceptor
+	dryRun       *dryRun
+	audit        AuditSink
+	invoke       Invoker // The interceptors around send
 }
 
-// NewClient creates a new instance of the API client
-func NewClient(clientURL string, auth *ecdsa.PrivateKey) MevAPIClient {
-	flashbotsClient := flashbotsrpc.New(clientURL)
+var _ MevAPIClientContext = (*Client)(nil)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http client used for the requests, flashbotsrpc's default client is used otherwise
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Metrics receives the outcome of every call, see the prommetrics package for Prometheus collectors
+type Metrics interface {
+	// CallFinished is called with the method, how long the call took and its error, nil on success
+	CallFinished(method string, duration time.Duration, err error)
+}
+
+// WithMetrics reports every call to m
+func WithMetrics(m Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
 
-	return &Client{
-		httpClient:      &http.Client{},
-		flashbotsClient: flashbotsClient,
-		baseURL:         clientURL,
-		privKey:         auth,
+// WithTracer traces every call as a span, see the oteltracing package for OpenTelemetry
+func WithTracer(tracer tracing.Tracer) Option {
+	return func(c *Client) {
+		c.tracer = tracer
 	}
 }
 
+// NewClient creates a new instance of the API client
+func NewClient(clientURL string, auth *ecdsa.PrivateKey, opts ...Option) MevAPIClient {
+	c := &Client{
+		baseURL: clientURL,
+		privKey: auth,
+		tracer:  tracing.Noop,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	interceptors := c.interceptors
+	if c.dryRun != nil {
+		c.dryRun.client = c
+		interceptors = append(interceptors[:len(interceptors):len(interceptors)], c.dryRun.intercept)
+	}
+	c.invoke = c.measure(chain(interceptors, c.callWithSig))
+
+	return c
+}
+
 // Does api requests with Flashbots signature header
 // returns the body
 func (c *Client) CallWithSig(method string, params ...interface{}) ([]byte, error) {
-	return c.flashbotsClient.CallWithFlashbotsSignature(method, c.privKey, params...)
+	return c.CallWithSigContext(context.Background(), method, params...)
+}
+
+// CallWithSigContext is CallWithSig with a context, the call is traced as a child of the span in ctx
+func (c *Client) CallWithSigContext(ctx context.Context, method string, params ...interface{}) (res []byte, err error) {
+	ctx, span := c.startSpan(ctx, method)
+	defer func() { tracing.End(span, err) }()
+
+	return c.invoke(ctx, method, params)
+}
+
+// startSpan starts the span of a call
+func (c *Client) startSpan(ctx context.Context, method string, attrs ...tracing.Attribute) (context.Context, tracing.Span) {
+	return c.tracer.Start(ctx, method, append([]tracing.Attribute{tracing.String(tracing.MethodKey, method)}, attrs...)...)
+}
+
+// measure reports the calls of invoke to the metrics, it is the outermost invoker so calls
+// rejected by an interceptor are counted too
+func (c *Client) measure(invoke Invoker) Invoker {
+	if c.metrics == nil {
+		return invoke
+	}
+	return func(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+		start := time.Now()
+		res, err := invoke(ctx, method, params)
+		c.metrics.CallFinished(method, time.Since(start), err)
+		return res, err
+	}
 }
 
 // Send private transaction ~`eth_sendPrivateTransaction`
@@ -41,9 +118,21 @@ func (c *Client) CallWithSig(method string, params ...interface{}) ([]byte, erro
 // options - options for private tx hints, builders, inclution, etc...
 // returns the Transaction hash of the sent transaction
 func (c *Client) SendPrivateTransaction(signedRawTx string, options *PrivateTxOptions) (*common.Hash, error) {
+	return c.SendPrivateTransactionContext(context.Background(), signedRawTx, options)
+}
+
+// SendPrivateTransactionContext is SendPrivateTransaction with a context, see CallWithSigContext
+func (c *Client) SendPrivateTransactionContext(ctx context.Context, signedRawTx string, options *PrivateTxOptions) (_ *common.Hash, err error) {
+	var attrs []tracing.Attribute
+	if options != nil && options.MaxBlockNumber != 0 {
+		attrs = append(attrs, tracing.Uint64(tracing.MaxBlockKey, uint64(options.MaxBlockNumber)))
+	}
+	ctx, span := c.startSpan(ctx, "eth_sendPrivateTransaction", attrs...)
+	defer func() { tracing.End(span, err) }()
+
 	tx := encodePrivateTxParams(signedRawTx, options)
 
-	res, err := c.CallWithSig("eth_sendPrivateTransaction", tx)
+	res, err := c.invoke(ctx, "eth_sendPrivateTransaction", []interface{}{tx})
 	if err != nil {
 		return nil, err
 	}
@@ -53,10 +142,20 @@ func (c *Client) SendPrivateTransaction(signedRawTx string, options *PrivateTxOp
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(tracing.String(tracing.TxHashKey, decoded.Hex()))
 
 	return &decoded, nil
 }
 
+// bundleAttributes describes the bundle on its span
+func bundleAttributes(bundle *SendMevBundleArgs) []tracing.Attribute {
+	attrs := []tracing.Attribute{tracing.Uint64(tracing.TargetBlockKey, uint64(bundle.Inclusion.BlockNumber))}
+	if bundle.Inclusion.MaxBlock != 0 {
+		attrs = append(attrs, tracing.Uint64(tracing.MaxBlockKey, uint64(bundle.Inclusion.MaxBlock)))
+	}
+	return attrs
+}
+
 type (
 	SendMevBundleArgs     = mevshare.SendMevBundleArgs
 	SendMevBundleResponse = mevshare.SendMevBundleResponse
@@ -74,8 +173,16 @@ type (
 // bundle - the bundle with all transactions / hashes
 // returns the bundle hash / error
 func (c *Client) SendBundle(bundle SendMevBundleArgs) (*mevshare.SendMevBundleResponse, error) {
+	return c.SendBundleContext(context.Background(), bundle)
+}
+
+// SendBundleContext is SendBundle with a context, see CallWithSigContext
+func (c *Client) SendBundleContext(ctx context.Context, bundle SendMevBundleArgs) (_ *mevshare.SendMevBundleResponse, err error) {
 	bundle.Version = "v0.1"
-	res, err := c.CallWithSig("mev_sendBundle", bundle)
+	ctx, span := c.startSpan(ctx, "mev_sendBundle", bundleAttributes(&bundle)...)
+	defer func() { tracing.End(span, err) }()
+
+	res, err := c.invoke(ctx, "mev_sendBundle", []interface{}{bundle})
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +192,7 @@ func (c *Client) SendBundle(bundle SendMevBundleArgs) (*mevshare.SendMevBundleRe
 	if err != nil {
 		return nil, err
 	}
+	span.SetAttributes(tracing.String(tracing.BundleHashKey, decoded.BundleHash.Hex()))
 
 	return &decoded, nil
 }
@@ -99,8 +207,16 @@ type (
 // simOverrides - given values will be overwritten when doing the simulation
 // returns the simulation result / error
 func (c *Client) SimBundle(bundle mevshare.SendMevBundleArgs, simOverrides mevshare.SimMevBundleAuxArgs) (*mevshare.SimMevBundleResponse, error) {
+	return c.SimBundleContext(context.Background(), bundle, simOverrides)
+}
+
+// SimBundleContext is SimBundle with a context, see CallWithSigContext
+func (c *Client) SimBundleContext(ctx context.Context, bundle mevshare.SendMevBundleArgs, simOverrides mevshare.SimMevBundleAuxArgs) (_ *mevshare.SimMevBundleResponse, err error) {
 	bundle.Version = "v0.1"
-	res, err := c.CallWithSig("mev_simBundle", bundle, simOverrides)
+	ctx, span := c.startSpan(ctx, "mev_simBundle", bundleAttributes(&bundle)...)
+	defer func() { tracing.End(span, err) }()
+
+	res, err := c.invoke(ctx, "mev_simBundle", []interface{}{bundle, simOverrides})
 	if err != nil {
 		return nil, err
 	}
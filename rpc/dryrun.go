@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/flashbots/mev-share-node/mevshare"
+)
+
+// Dry-run actions, see DryRunRecord
+const (
+	DryRunSimulated = "simulated" // A bundle was simulated instead of sent
+	DryRunSkipped   = "skipped"   // Nothing was sent
+	DryRunForwarded = "forwarded" // A read-only call was sent as is
+)
+
+// dryRunMarker starts every hash made up by a dry run
+var dryRunMarker = []byte("dry-run\x00")
+
+// IsDryRun tells whether a hash returned by the client was made up by a dry run
+func IsDryRun(hash common.Hash) bool {
+	return bytes.HasPrefix(hash[:], dryRunMarker)
+}
+
+// DryRunRecord is a call intercepted by a dry run
+type DryRunRecord struct {
+	Time       time.Time       `json:"time"`
+	Method     string          `json:"method"`
+	Params     json.RawMessage `json:"params"`               // The params that would have been sent
+	Action     string          `json:"action"`               // What was done instead
+	Simulation json.RawMessage `json:"simulation,omitempty"` // The simulation result of a bundle
+	Response   json.RawMessage `json:"response,omitempty"`   // The synthetic response returned
+	Error      string          `json:"error,omitempty"`
+}
+
+// WithDryRun sends nothing that could land on chain: SendBundle simulates the bundle instead and
+// SendPrivateTransaction does nothing. Both return synthetic responses whose hashes satisfy IsDryRun.
+// SimBundle is sent as usual, other methods are skipped and return null.
+// Every call is written to report as a JSON DryRunRecord per line, report may be nil.
+// The dry run is innermost, the interceptors see the calls as made.
+func WithDryRun(report io.Writer) Option {
+	return func(c *Client) {
+		c.dryRun = &dryRun{report: report}
+	}
+}
+
+// dryRun intercepts the calls of a client in dry-run mode
+type dryRun struct {
+	client *Client
+
+	mu     sync.Mutex
+	report io.Writer
+}
+
+func (d *dryRun) intercept(ctx context.Context, method string, params []interface{}, next Invoker) (json.RawMessage, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotSent, err)
+	}
+	record := DryRunRecord{Time: time.Now(), Method: method, Params: encoded}
+
+	var res json.RawMessage
+	switch method {
+	case "mev_simBundle":
+		record.Action = DryRunForwarded
+		res, err = next(ctx, method, params)
+		record.Response = res
+	case "mev_sendBundle":
+		record.Action = DryRunSimulated
+		res, err = d.simulate(ctx, method, params, encoded, next, &record)
+	case "eth_sendPrivateTransaction":
+		record.Action = DryRunSkipped
+		res, err = json.Marshal(dryRunHash(method, encoded))
+		record.Response = res
+	default:
+		record.Action = DryRunSkipped
+		res = json.RawMessage("null")
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	d.client.log().Info("dry run", "method", method, "action", record.Action, "err", err)
+	d.write(record)
+	return res, err
+}
+
+// simulate simulates the bundle of a mev_sendBundle call and makes up its response
+func (d *dryRun) simulate(ctx context.Context, method string, params []interface{}, encoded json.RawMessage, next Invoker, record *DryRunRecord) (json.RawMessage, error) {
+	if len(params) != 1 {
+		return json.Marshal(mevshare.SendMevBundleResponse{BundleHash: dryRunHash(method, encoded)})
+	}
+
+	// The bundle is not sent whatever happens to its simulation
+	sim, err := next(ctx, "mev_simBundle", []interface{}{params[0], mevshare.SimMevBundleAuxArgs{}})
+	if err != nil {
+		return nil, fmt.Errorf("%w: simulation: %w", ErrNotSent, err)
+	}
+	record.Simulation = sim
+
+	res, err := json.Marshal(mevshare.SendMevBundleResponse{BundleHash: dryRunHash(method, encoded)})
+	record.Response = res
+	return res, err
+}
+
+// write appends the record to the report
+func (d *dryRun) write(record DryRunRecord) {
+	if d.report == nil {
+		return
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.report.Write(append(line, '\n')); err != nil {
+		d.client.log().Warn("dry run report failed", "err", err)
+	}
+}
+
+// dryRunHash makes up the hash of a call, it is deterministic and satisfies IsDryRun
+func dryRunHash(method string, params []byte) common.Hash {
+	hash := crypto.Keccak256Hash([]byte(method), params)
+	copy(hash[:], dryRunMarker)
+	return hash
+}
@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_DryRun(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Method string `json:"method"`
+		}
+		_ = json.Unmarshal(body, &req)
+		methods = append(methods, req.Method)
+
+		if len(methods) == 3 {
+			_, _ = w.Write([]byte(`{"error":"simulation unavailable"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"success":true,"profit":"0x1"}}`))
+	}))
+	defer server.Close()
+
+	var report bytes.Buffer
+	client := NewClient(server.URL, key, WithDryRun(&report))
+
+	bundle := SendMevBundleArgs{Inclusion: Inclusion{BlockNumber: 100}}
+	sent, err := client.SendBundle(bundle)
+	require.NoError(t, err)
+	assert.True(t, IsDryRun(sent.BundleHash))
+
+	again, err := client.SendBundle(bundle)
+	require.NoError(t, err)
+	assert.Equal(t, sent.BundleHash, again.BundleHash)
+
+	_, err = client.SendBundle(bundle)
+	assert.ErrorIs(t, err, ErrRelayErrorResponse)
+	assert.ErrorIs(t, err, ErrNotSent)
+
+	txHash, err := client.SendPrivateTransaction("0x01", &PrivateTxOptions{})
+	require.NoError(t, err)
+	assert.True(t, IsDryRun(*txHash))
+
+	sim, err := client.SimBundle(bundle, SimMevBundleAuxArgs{})
+	require.NoError(t, err)
+	assert.True(t, sim.Success)
+
+	res, err := client.CallWithSig("mev_cancelBundle", "0x01")
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(res))
+
+	// Only simulations reached the node
+	assert.Equal(t, []string{"mev_simBundle", "mev_simBundle", "mev_simBundle", "mev_simBundle"}, methods)
+
+	var records []DryRunRecord
+	scanner := bufio.NewScanner(&report)
+	for scanner.Scan() {
+		var record DryRunRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 6)
+
+	assert.Equal(t, "mev_sendBundle", records[0].Method)
+	assert.Equal(t, DryRunSimulated, records[0].Action)
+	assert.JSONEq(t, `{"success":true,"profit":"0x1"}`, string(records[0].Simulation))
+	assert.Contains(t, string(records[0].Params), `"block":"0x64"`)
+	assert.Contains(t, string(records[0].Response), sent.BundleHash.Hex())
+
+	assert.Equal(t, "request not sent: simulation: relay error response: simulation unavailable", records[2].Error)
+	assert.Empty(t, records[2].Response)
+
+	assert.Equal(t, DryRunSkipped, records[3].Action)
+	assert.Contains(t, string(records[3].Params), `"tx":"0x01"`)
+	assert.Equal(t, DryRunForwarded, records[4].Action)
+	assert.Equal(t, DryRunSkipped, records[5].Action)
+	assert.Equal(t, "mev_cancelBundle", records[5].Method)
+}
+
+func TestClient_DryRunInterceptors(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	var seen []string
+	client := NewClient("http://127.0.0.1:0", key, WithDryRun(nil), WithInterceptors(Interceptor(
+		func(ctx context.Context, method string, params []interface{}, next Invoker) (json.RawMessage, error) {
+			seen = append(seen, method)
+			return next(ctx, method, params)
+		},
+	)))
+
+	hash, err := client.SendPrivateTransaction("0x01", &PrivateTxOptions{})
+	require.NoError(t, err)
+	assert.True(t, IsDryRun(*hash))
+	assert.Equal(t, []string{"eth_sendPrivateTransaction"}, seen)
+}
+
+func TestIsDryRun(t *testing.T) {
+	assert.False(t, IsDryRun(common.Hash{}))
+	assert.False(t, IsDryRun(crypto.Keccak256Hash([]byte("tx"))))
+	assert.True(t, IsDryRun(dryRunHash("eth_sendPrivateTransaction", []byte("[]"))))
+}
@@ -0,0 +1,31 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Invoker sends a call and returns its raw result
+type Invoker func(ctx context.Context, method string, params []interface{}) (json.RawMessage, error)
+
+// Interceptor wraps every call of a Client, CallWithSig and the typed methods alike.
+// It may inspect or change the call, return without calling next, or call next several times, e.g. to retry.
+type Interceptor func(ctx context.Context, method string, params []interface{}, next Invoker) (json.RawMessage, error)
+
+// WithInterceptors installs interceptors, the first one given is the outermost
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// chain wraps the invoker in the interceptors, the first one ends up outermost
+func chain(interceptors []Interceptor, invoker Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+			return interceptor(ctx, method, params, next)
+		}
+	}
+	return invoker
+}
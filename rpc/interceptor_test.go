@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Interceptors(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0000000000000000000000000000000000000000000000000000000000000001"}}`))
+	}))
+	defer server.Close()
+
+	type ctxKey struct{}
+	var order []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, method string, params []interface{}, next Invoker) (json.RawMessage, error) {
+			order = append(order, name+" "+method)
+			assert.Equal(t, "value", ctx.Value(ctxKey{}))
+			return next(ctx, method, params)
+		}
+	}
+	overrideBlock := func(ctx context.Context, method string, params []interface{}, next Invoker) (json.RawMessage, error) {
+		if bundle, ok := params[0].(SendMevBundleArgs); ok {
+			bundle.Inclusion.BlockNumber = 42
+			params = []interface{}{bundle}
+		}
+		return next(ctx, method, params)
+	}
+
+	client := NewClient(server.URL, key, WithInterceptors(record("outer"), record("inner")), WithInterceptors(overrideBlock)).(MevAPIClientContext)
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	res, err := client.SendBundleContext(ctx, SendMevBundleArgs{})
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToHash("0x01"), res.BundleHash)
+	assert.Equal(t, []string{"outer mev_sendBundle", "inner mev_sendBundle"}, order)
+	require.Len(t, bodies, 1)
+	assert.Contains(t, bodies[0], `"block":"0x2a"`)
+
+	_, err = client.CallWithSigContext(ctx, "mev_test", "0x1")
+	require.NoError(t, err)
+	assert.Equal(t, "inner mev_test", order[len(order)-1])
+}
+
+func TestClient_InterceptorShortCircuit(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	errBlocked := errors.New("blocked")
+	calls := 0
+	client := NewClient("http://127.0.0.1:0", key, WithInterceptors(
+		func(ctx context.Context, method string, params []interface{}, next Invoker) (json.RawMessage, error) {
+			calls++
+			if method == "eth_sendPrivateTransaction" {
+				return nil, errBlocked
+			}
+			return json.RawMessage(`{"success":true}`), nil
+		},
+	))
+
+	_, err = client.SendPrivateTransaction("0x01", &PrivateTxOptions{})
+	assert.ErrorIs(t, err, errBlocked)
+
+	sim, err := client.SimBundle(SendMevBundleArgs{}, SimMevBundleAuxArgs{})
+	require.NoError(t, err)
+	assert.True(t, sim.Success)
+
+	_, err = client.CallWithSig("mev_test")
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
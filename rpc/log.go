@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/duoxehyon/mev-share-go/internal/logging"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// WithLogger logs every call with its request and response sizes, nothing is logged by default.
+// Request bodies are only logged at debug level, with raw transactions replaced by their hashes.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// log returns the configured logger or one that discards the records
+func (c *Client) log() *slog.Logger {
+	return logging.OrDiscard(c.logger)
+}
+
+// LogValue logs the client by its endpoint and signer, never its key
+func (c *Client) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("url", c.baseURL)}
+	if c.privKey != nil {
+		attrs = append(attrs, slog.String("signer", crypto.PubkeyToAddress(c.privKey.PublicKey).Hex()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// signer is the address part of a signature header, the signature itself is not logged
+func signer(signature string) string {
+	address, _, _ := strings.Cut(signature, ":")
+	return address
+}
+
+// redactedBody logs a request body with its raw transactions replaced by their hashes.
+// The redaction only runs when the record is logged.
+type redactedBody []byte
+
+// LogValue implements slog.LogValuer
+func (b redactedBody) LogValue() slog.Value {
+	var body interface{}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return slog.StringValue("[unparsable body redacted]")
+	}
+
+	redacted, err := json.Marshal(redact(body, ""))
+	if err != nil {
+		return slog.StringValue("[unparsable body redacted]")
+	}
+	return slog.StringValue(string(redacted))
+}
+
+// redact replaces the raw transactions, the "tx" and "txs" fields, of a decoded JSON value
+func redact(value interface{}, key string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			v[k] = redact(field, k)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item, key)
+		}
+	case string:
+		if key == "tx" || key == "txs" {
+			return redactTx(v)
+		}
+	}
+	return value
+}
+
+// redactTx replaces a raw transaction by its hash
+func redactTx(raw string) string {
+	data, err := hexutil.Decode(raw)
+	if err != nil {
+		return "[redacted]"
+	}
+	return "[redacted tx " + crypto.Keccak256Hash(data).Hex() + "]"
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"bytes"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Logger(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)), &types.LegacyTx{
+		Nonce:    1,
+		To:       &common.Address{},
+		Gas:      21000,
+		GasPrice: big.NewInt(1),
+	})
+	require.NoError(t, err)
+	rawTx, err := tx.MarshalBinary()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too low"}}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient(server.URL, key, WithLogger(logger))
+
+	_, err = client.SendPrivateTransaction(hexutil.Encode(rawTx), &PrivateTxOptions{})
+	require.Error(t, err)
+	logger.Info("client", "client", client)
+
+	output := buf.String()
+	assert.Contains(t, output, "msg=\"rpc request\" method=eth_sendPrivateTransaction")
+	assert.Contains(t, output, "msg=\"rpc response\" method=eth_sendPrivateTransaction status=200")
+	assert.Contains(t, output, "msg=\"rpc error\" method=eth_sendPrivateTransaction err=\"relay error response: nonce too low\"")
+	assert.Contains(t, output, tx.Hash().Hex())
+	assert.Contains(t, output, crypto.PubkeyToAddress(key.PublicKey).Hex())
+	assert.NotContains(t, output, hexutil.Encode(rawTx)[2:])
+	assert.NotContains(t, output, hexutil.Encode(crypto.FromECDSA(key))[2:])
+}
+
+func TestRedactedBody(t *testing.T) {
+	body := redactedBody(`{"params":[{"body":[{"tx":"0x01","canRevert":false},{"hash":"0x02"}]}],"txs":["0x03","nothex"]}`)
+	assert.JSONEq(t, `{"params":[{"body":[{"tx":"`+redactTx("0x01")+`","canRevert":false},{"hash":"0x02"}]}],"txs":["`+redactTx("0x03")+`","[redacted]"]}`, body.LogValue().String())
+	assert.Equal(t, "[redacted tx "+crypto.Keccak256Hash([]byte{1}).Hex()+"]", redactTx("0x01"))
+	assert.Equal(t, "[unparsable body redacted]", redactedBody("{").LogValue().String())
+}
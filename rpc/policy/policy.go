@@ -0,0 +1,418 @@
+// Package policy enforces spending and risk rules on the transactions an rpc.Client submits.
+//
+// A Policy is installed as an interceptor, ahead of signing:
+//
+//	p := policy.New(policy.Rules{MaxValue: big.NewInt(1e18), AllowedTo: []common.Address{router}})
+//	client := rpc.NewClient(url, key, rpc.WithInterceptors(p.Interceptor()))
+//
+// It decodes the signed transactions of mev_sendBundle and eth_sendPrivateTransaction calls,
+// other calls pass unchecked. Rejected calls fail with a *Violation and are never sent.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrViolation is matched by every *Violation with errors.Is
+var ErrViolation = errors.New("policy violation")
+
+// Rule is a rule of a Policy
+type Rule string
+
+// The rules a transaction can violate
+const (
+	RuleMalformed      Rule = "malformed"        // The transaction could not be decoded
+	RuleMaxValue       Rule = "max_value"        // Rules.MaxValue
+	RuleMaxGasPrice    Rule = "max_gas_price"    // Rules.MaxGasPrice
+	RuleMaxPriorityFee Rule = "max_priority_fee" // Rules.MaxPriorityFee
+	RuleAllowedTo      Rule = "allowed_to"       // Rules.AllowedTo
+	RuleCanRevert      Rule = "can_revert"       // Rules.CanRevert
+	RuleBlockSpend     Rule = "block_spend"      // Rules.MaxSpendPerBlock
+	RuleHourlySpend    Rule = "hourly_spend"     // Rules.MaxSpendPerHour
+)
+
+// Violation is a call rejected by a Policy
+type Violation struct {
+	Rule   Rule
+	Method string
+	Tx     common.Hash // The offending transaction, zero if it could not be decoded
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	if v.Tx == (common.Hash{}) {
+		return fmt.Sprintf("%s: %s: %s: %s", ErrViolation, v.Method, v.Rule, v.Reason)
+	}
+	return fmt.Sprintf("%s: %s: %s: tx %s: %s", ErrViolation, v.Method, v.Rule, v.Tx.Hex(), v.Reason)
+}
+
+// Is makes errors.Is(err, ErrViolation) hold
+func (v *Violation) Is(target error) bool {
+	return target == ErrViolation
+}
+
+// CanRevertRule is what a Policy requires of the canRevert flags of bundled transactions
+type CanRevertRule int
+
+const (
+	// AnyCanRevert accepts either flag
+	AnyCanRevert CanRevertRule = iota
+	// RequireCanRevert requires every bundled transaction to be allowed to revert
+	RequireCanRevert
+	// ForbidCanRevert requires every bundled transaction to succeed for the bundle to land
+	ForbidCanRevert
+)
+
+// Rules are the limits of a Policy, nil and empty limits are not enforced
+type Rules struct {
+	MaxValue       *big.Int         // Value of a transaction
+	MaxGasPrice    *big.Int         // Gas price, or fee cap of dynamic fee transactions
+	MaxPriorityFee *big.Int         // Priority fee, the gas price of legacy transactions
+	AllowedTo      []common.Address // Recipients allowed, contract creations are rejected once set
+	CanRevert      CanRevertRule
+
+	// Total cost, value plus gas limit times fee cap, of the transactions submitted for a block and within an hour.
+	// A transaction resubmitted for the same block, or within the hour, is counted once.
+	// Bundles count for their target block, private transactions for their max block and not per block without one.
+	MaxSpendPerBlock *big.Int
+	MaxSpendPerHour  *big.Int
+}
+
+// spend is the cost of a transaction counted in the hourly total
+type spend struct {
+	at   time.Time
+	tx   common.Hash
+	cost *big.Int
+}
+
+// Policy enforces the rules on the calls of an rpc.Client, it is safe for concurrent use
+type Policy struct {
+	rules     Rules
+	allowedTo map[common.Address]struct{}
+	now       func() time.Time
+
+	mu     sync.Mutex
+	blocks map[uint64]map[common.Hash]*big.Int // Costs counted per block
+	hourly []spend
+}
+
+// New creates a policy enforcing the rules
+func New(rules Rules) *Policy {
+	p := &Policy{
+		rules:  rules,
+		now:    time.Now,
+		blocks: make(map[uint64]map[common.Hash]*big.Int),
+	}
+	if len(rules.AllowedTo) > 0 {
+		p.allowedTo = make(map[common.Address]struct{}, len(rules.AllowedTo))
+		for _, to := range rules.AllowedTo {
+			p.allowedTo[to] = struct{}{}
+		}
+	}
+	return p
+}
+
+// Interceptor returns the interceptor checking the calls of a client, install it with rpc.WithInterceptors.
+// The spend of a call is released again only if the call provably did not leave the client: it failed with
+// rpc.ErrNotSent or a dry run made up its response. Any other failure, e.g. a timeout, may have reached
+// the node and stays counted.
+func (p *Policy) Interceptor() rpc.Interceptor {
+	return func(ctx context.Context, method string, params []interface{}, next rpc.Invoker) (json.RawMessage, error) {
+		release, err := p.Admit(method, params)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := next(ctx, method, params)
+		if notSent(res, err) {
+			release()
+		}
+		return res, err
+	}
+}
+
+// notSent tells whether a call did not leave the client, from its result
+func notSent(res json.RawMessage, err error) bool {
+	if err != nil {
+		return errors.Is(err, rpc.ErrNotSent)
+	}
+
+	// eth_sendPrivateTransaction returns a hash, mev_sendBundle an object with one
+	var hash common.Hash
+	if json.Unmarshal(res, &hash) == nil {
+		return rpc.IsDryRun(hash)
+	}
+	var bundle rpc.SendMevBundleResponse
+	if json.Unmarshal(res, &bundle) == nil {
+		return rpc.IsDryRun(bundle.BundleHash)
+	}
+	return false
+}
+
+// Admit checks a call and counts its spend, release uncounts it if the call is not sent after all
+func (p *Policy) Admit(method string, params []interface{}) (release func(), err error) {
+	txs, block, err := outgoing(method, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(txs) == 0 {
+		return func() {}, nil
+	}
+
+	for _, tx := range txs {
+		if err := p.check(method, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.count(method, txs, block)
+}
+
+// outgoingTx is a signed transaction of a call
+type outgoingTx struct {
+	tx        *types.Transaction
+	bundled   bool
+	canRevert bool
+}
+
+// outgoing decodes the signed transactions of a call and the block they are for, zero if unknown
+func outgoing(method string, params []interface{}) ([]outgoingTx, uint64, error) {
+	if len(params) == 0 {
+		return nil, 0, nil
+	}
+
+	switch method {
+	case "eth_sendPrivateTransaction":
+		var args struct {
+			Tx             string         `json:"tx"`
+			MaxBlockNumber hexutil.Uint64 `json:"maxBlockNumber"`
+		}
+		if err := remarshal(params[0], &args); err != nil {
+			return nil, 0, &Violation{Rule: RuleMalformed, Method: method, Reason: err.Error()}
+		}
+		raw, err := hexutil.Decode(args.Tx)
+		if err != nil {
+			return nil, 0, &Violation{Rule: RuleMalformed, Method: method, Reason: err.Error()}
+		}
+		tx, err := decodeTx(method, raw)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []outgoingTx{{tx: tx}}, uint64(args.MaxBlockNumber), nil
+
+	case "mev_sendBundle":
+		var bundle rpc.SendMevBundleArgs
+		if err := remarshal(params[0], &bundle); err != nil {
+			return nil, 0, &Violation{Rule: RuleMalformed, Method: method, Reason: err.Error()}
+		}
+		txs, err := bundleTxs(method, &bundle, nil)
+		return txs, uint64(bundle.Inclusion.BlockNumber), err
+	}
+
+	return nil, 0, nil
+}
+
+// bundleTxs appends the signed transactions of the bundle and its nested bundles
+func bundleTxs(method string, bundle *rpc.SendMevBundleArgs, txs []outgoingTx) ([]outgoingTx, error) {
+	for _, body := range bundle.Body {
+		switch {
+		case body.Tx != nil:
+			tx, err := decodeTx(method, *body.Tx)
+			if err != nil {
+				return nil, err
+			}
+			txs = append(txs, outgoingTx{tx: tx, bundled: true, canRevert: body.CanRevert})
+		case body.Bundle != nil:
+			var err error
+			if txs, err = bundleTxs(method, body.Bundle, txs); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return txs, nil
+}
+
+func decodeTx(method string, raw []byte) (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, &Violation{Rule: RuleMalformed, Method: method, Reason: err.Error()}
+	}
+	return tx, nil
+}
+
+// remarshal decodes a param through JSON, params are typed values or already decoded JSON
+func remarshal(param interface{}, target interface{}) error {
+	data, err := json.Marshal(param)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// check enforces the rules of a single transaction
+func (p *Policy) check(method string, out outgoingTx) error {
+	tx := out.tx
+	violation := func(rule Rule, format string, args ...interface{}) error {
+		return &Violation{Rule: rule, Method: method, Tx: tx.Hash(), Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if max := p.rules.MaxValue; max != nil && tx.Value().Cmp(max) > 0 {
+		return violation(RuleMaxValue, "value %s exceeds %s", tx.Value(), max)
+	}
+	if max := p.rules.MaxGasPrice; max != nil && tx.GasFeeCap().Cmp(max) > 0 {
+		return violation(RuleMaxGasPrice, "gas price %s exceeds %s", tx.GasFeeCap(), max)
+	}
+	if max := p.rules.MaxPriorityFee; max != nil && tx.GasTipCap().Cmp(max) > 0 {
+		return violation(RuleMaxPriorityFee, "priority fee %s exceeds %s", tx.GasTipCap(), max)
+	}
+	if p.allowedTo != nil {
+		if tx.To() == nil {
+			return violation(RuleAllowedTo, "contract creation is not allowed")
+		}
+		if _, ok := p.allowedTo[*tx.To()]; !ok {
+			return violation(RuleAllowedTo, "recipient %s is not allowed", tx.To().Hex())
+		}
+	}
+	if out.bundled {
+		switch {
+		case p.rules.CanRevert == RequireCanRevert && !out.canRevert:
+			return violation(RuleCanRevert, "canRevert must be set")
+		case p.rules.CanRevert == ForbidCanRevert && out.canRevert:
+			return violation(RuleCanRevert, "canRevert must not be set")
+		}
+	}
+	return nil
+}
+
+// count adds the cost of the transactions to the block and hourly totals unless that exceeds a limit
+func (p *Policy) count(method string, txs []outgoingTx, block uint64) (func(), error) {
+	if p.rules.MaxSpendPerBlock == nil && p.rules.MaxSpendPerHour == nil {
+		return func() {}, nil
+	}
+
+	now := p.now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(now)
+
+	blockCosts := p.blocks[block]
+	countBlock := block != 0 && p.rules.MaxSpendPerBlock != nil
+	inBlock := make(map[common.Hash]struct{}, len(blockCosts))
+	for hash := range blockCosts {
+		inBlock[hash] = struct{}{}
+	}
+	hourly := make(map[common.Hash]struct{}, len(p.hourly))
+	for _, s := range p.hourly {
+		hourly[s.tx] = struct{}{}
+	}
+
+	blockTotal, hourTotal := new(big.Int), new(big.Int)
+	for _, cost := range blockCosts {
+		blockTotal.Add(blockTotal, cost)
+	}
+	for _, s := range p.hourly {
+		hourTotal.Add(hourTotal, s.cost)
+	}
+
+	var newBlock, newHourly []outgoingTx
+	for _, out := range txs {
+		hash := out.tx.Hash()
+		if _, ok := inBlock[hash]; countBlock && !ok {
+			newBlock = append(newBlock, out)
+			blockTotal.Add(blockTotal, out.tx.Cost())
+			inBlock[hash] = struct{}{}
+		}
+		if _, ok := hourly[hash]; !ok {
+			newHourly = append(newHourly, out)
+			hourTotal.Add(hourTotal, out.tx.Cost())
+			hourly[hash] = struct{}{}
+		}
+	}
+
+	if countBlock && blockTotal.Cmp(p.rules.MaxSpendPerBlock) > 0 {
+		return nil, &Violation{Rule: RuleBlockSpend, Method: method, Tx: txs[0].tx.Hash(),
+			Reason: fmt.Sprintf("spend %s for block %d exceeds %s", blockTotal, block, p.rules.MaxSpendPerBlock)}
+	}
+	if max := p.rules.MaxSpendPerHour; max != nil && hourTotal.Cmp(max) > 0 {
+		return nil, &Violation{Rule: RuleHourlySpend, Method: method, Tx: txs[0].tx.Hash(),
+			Reason: fmt.Sprintf("spend %s within the hour exceeds %s", hourTotal, max)}
+	}
+
+	if len(newBlock) > 0 {
+		if blockCosts == nil {
+			blockCosts = make(map[common.Hash]*big.Int)
+			p.blocks[block] = blockCosts
+		}
+		for _, out := range newBlock {
+			blockCosts[out.tx.Hash()] = out.tx.Cost()
+		}
+	}
+	for _, out := range newHourly {
+		p.hourly = append(p.hourly, spend{at: now, tx: out.tx.Hash(), cost: out.tx.Cost()})
+	}
+
+	return func() { p.release(block, newBlock, newHourly) }, nil
+}
+
+// release uncounts transactions of a call that was not sent
+func (p *Policy) release(block uint64, newBlock, newHourly []outgoingTx) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, out := range newBlock {
+		delete(p.blocks[block], out.tx.Hash())
+	}
+	if len(p.blocks[block]) == 0 {
+		delete(p.blocks, block)
+	}
+
+	released := make(map[common.Hash]struct{}, len(newHourly))
+	for _, out := range newHourly {
+		released[out.tx.Hash()] = struct{}{}
+	}
+	kept := p.hourly[:0]
+	for _, s := range p.hourly {
+		if _, ok := released[s.tx]; !ok {
+			kept = append(kept, s)
+		}
+	}
+	p.hourly = kept
+}
+
+// blockRetention is how many blocks below the newest one the block totals are kept for
+const blockRetention = 64
+
+// prune drops the hourly spend older than an hour and the totals of old blocks
+func (p *Policy) prune(now time.Time) {
+	cutoff := now.Add(-time.Hour)
+	i := 0
+	for i < len(p.hourly) && !p.hourly[i].at.After(cutoff) {
+		i++
+	}
+	p.hourly = p.hourly[i:]
+
+	var newest uint64
+	for block := range p.blocks {
+		if block > newest {
+			newest = block
+		}
+	}
+	for block := range p.blocks {
+		if block+blockRetention < newest {
+			delete(p.blocks, block)
+		}
+	}
+}
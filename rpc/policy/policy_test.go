@@ -0,0 +1,265 @@
+package policy
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	router = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	other  = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+)
+
+type testTx struct {
+	to        *common.Address
+	value     int64
+	gasFeeCap int64
+	tip       int64
+	nonce     uint64
+}
+
+func signTx(t *testing.T, key *ecdsa.PrivateKey, tx testTx) hexutil.Bytes {
+	signed, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     tx.nonce,
+		To:        tx.to,
+		Value:     big.NewInt(tx.value),
+		Gas:       10,
+		GasFeeCap: big.NewInt(tx.gasFeeCap),
+		GasTipCap: big.NewInt(tx.tip),
+	})
+	require.NoError(t, err)
+	raw, err := signed.MarshalBinary()
+	require.NoError(t, err)
+	return raw
+}
+
+func bundle(block uint64, canRevert bool, txs ...hexutil.Bytes) rpc.SendMevBundleArgs {
+	args := rpc.SendMevBundleArgs{Inclusion: rpc.Inclusion{BlockNumber: hexutil.Uint64(block)}}
+	hash := common.HexToHash("0x01")
+	args.Body = append(args.Body, rpc.MevBundleBody{Hash: &hash})
+	for i := range txs {
+		args.Body = append(args.Body, rpc.MevBundleBody{Tx: &txs[i], CanRevert: canRevert})
+	}
+	return args
+}
+
+func newClient(t *testing.T, p *Policy) (rpc.MevAPIClient, *int) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	sent := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "eth_sendPrivateTransaction") {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x0000000000000000000000000000000000000000000000000000000000000001"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"bundleHash":"0x0000000000000000000000000000000000000000000000000000000000000001","success":true}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return rpc.NewClient(server.URL, key, rpc.WithInterceptors(p.Interceptor())), &sent
+}
+
+func violation(t *testing.T, err error) *Violation {
+	t.Helper()
+	var v *Violation
+	require.ErrorAs(t, err, &v)
+	assert.ErrorIs(t, err, ErrViolation)
+	return v
+}
+
+func TestPolicy_Rules(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	p := New(Rules{
+		MaxValue:       big.NewInt(100),
+		MaxGasPrice:    big.NewInt(50),
+		MaxPriorityFee: big.NewInt(5),
+		AllowedTo:      []common.Address{router},
+		CanRevert:      ForbidCanRevert,
+	})
+	client, sent := newClient(t, p)
+
+	ok := signTx(t, key, testTx{to: &router, value: 100, gasFeeCap: 50, tip: 5})
+	_, err = client.SendBundle(bundle(1, false, ok))
+	require.NoError(t, err)
+	_, err = client.SendPrivateTransaction(ok.String(), &rpc.PrivateTxOptions{})
+	require.NoError(t, err)
+
+	for rule, tx := range map[Rule]testTx{
+		RuleMaxValue:       {to: &router, value: 101, gasFeeCap: 50, tip: 5},
+		RuleMaxGasPrice:    {to: &router, value: 1, gasFeeCap: 51, tip: 5},
+		RuleMaxPriorityFee: {to: &router, value: 1, gasFeeCap: 50, tip: 6},
+		RuleAllowedTo:      {to: &other, value: 1, gasFeeCap: 50, tip: 5},
+	} {
+		raw := signTx(t, key, tx)
+		_, err := client.SendBundle(bundle(1, false, ok, raw))
+		v := violation(t, err)
+		assert.Equal(t, rule, v.Rule)
+		assert.Equal(t, "mev_sendBundle", v.Method)
+		assert.Equal(t, crypto.Keccak256Hash(raw), v.Tx)
+
+		_, err = client.SendPrivateTransaction(raw.String(), &rpc.PrivateTxOptions{})
+		assert.Equal(t, rule, violation(t, err).Rule)
+	}
+
+	_, err = client.SendPrivateTransaction(signTx(t, key, testTx{gasFeeCap: 1}).String(), &rpc.PrivateTxOptions{})
+	v := violation(t, err)
+	assert.Equal(t, RuleAllowedTo, v.Rule)
+	assert.Contains(t, v.Error(), "contract creation is not allowed")
+
+	_, err = client.SendBundle(bundle(1, true, ok))
+	assert.Equal(t, RuleCanRevert, violation(t, err).Rule)
+
+	_, err = client.SendPrivateTransaction("0x01", &rpc.PrivateTxOptions{})
+	v = violation(t, err)
+	assert.Equal(t, RuleMalformed, v.Rule)
+	assert.NotContains(t, v.Error(), "tx 0x")
+
+	// Simulations are not submissions
+	_, err = client.SimBundle(bundle(1, true, signTx(t, key, testTx{to: &other, value: 1000})), rpc.SimMevBundleAuxArgs{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, *sent)
+}
+
+func TestPolicy_NestedBundle(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	client, sent := newClient(t, New(Rules{CanRevert: RequireCanRevert}))
+
+	inner := bundle(1, false, signTx(t, key, testTx{to: &router}))
+	outer := bundle(1, true, signTx(t, key, testTx{to: &router, nonce: 1}))
+	outer.Body = append(outer.Body, rpc.MevBundleBody{Bundle: &inner})
+
+	_, err = client.SendBundle(outer)
+	assert.Equal(t, RuleCanRevert, violation(t, err).Rule)
+	assert.Zero(t, *sent)
+}
+
+func TestPolicy_Spend(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	// Every transaction costs its value plus 10 gas at a fee cap of 1
+	p := New(Rules{MaxSpendPerBlock: big.NewInt(100), MaxSpendPerHour: big.NewInt(150)})
+	p.now = func() time.Time { return now }
+	client, sent := newClient(t, p)
+
+	tx := func(nonce uint64, value int64) hexutil.Bytes {
+		return signTx(t, key, testTx{to: &router, value: value, gasFeeCap: 1, nonce: nonce})
+	}
+	first := tx(0, 50)
+
+	_, err = client.SendBundle(bundle(1, false, first))
+	require.NoError(t, err)
+	// Resubmitting is counted once
+	_, err = client.SendBundle(bundle(1, false, first))
+	require.NoError(t, err)
+	_, err = client.SendBundle(bundle(1, false, first, tx(1, 31)))
+	assert.Equal(t, RuleBlockSpend, violation(t, err).Rule)
+	_, err = client.SendBundle(bundle(1, false, first, tx(1, 30)))
+	require.NoError(t, err)
+
+	// The next block has room, the hour does not
+	_, err = client.SendBundle(bundle(2, false, tx(2, 41)))
+	assert.Equal(t, RuleHourlySpend, violation(t, err).Rule)
+	_, err = client.SendPrivateTransaction(tx(2, 40).String(), &rpc.PrivateTxOptions{})
+	require.NoError(t, err)
+	_, err = client.SendPrivateTransaction(tx(3, 0).String(), &rpc.PrivateTxOptions{})
+	assert.Equal(t, RuleHourlySpend, violation(t, err).Rule)
+
+	now = now.Add(time.Hour)
+	_, err = client.SendPrivateTransaction(tx(3, 0).String(), &rpc.PrivateTxOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 5, *sent)
+}
+
+func TestPolicy_SpendDuplicateInBundle(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	p := New(Rules{MaxSpendPerBlock: big.NewInt(100), MaxSpendPerHour: big.NewInt(100)})
+	client, sent := newClient(t, p)
+
+	// A transaction listed twice in a bundle is spent once
+	tx := signTx(t, key, testTx{to: &router, value: 50, gasFeeCap: 1})
+	_, err = client.SendBundle(bundle(1, false, tx, tx))
+	require.NoError(t, err)
+	assert.Equal(t, 1, *sent)
+}
+
+func TestPolicy_ReleaseFailed(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	p := New(Rules{MaxSpendPerHour: big.NewInt(100)})
+	tx := signTx(t, key, testTx{to: &router, value: 90, gasFeeCap: 1})
+
+	release, err := p.Admit("eth_sendPrivateTransaction", []interface{}{map[string]interface{}{"tx": tx.String()}})
+	require.NoError(t, err)
+
+	other := signTx(t, key, testTx{to: &router, value: 1, gasFeeCap: 1, nonce: 1})
+	_, err = p.Admit("eth_sendPrivateTransaction", []interface{}{map[string]interface{}{"tx": other.String()}})
+	assert.Equal(t, RuleHourlySpend, violation(t, err).Rule)
+
+	release()
+	_, err = p.Admit("eth_sendPrivateTransaction", []interface{}{map[string]interface{}{"tx": other.String()}})
+	require.NoError(t, err)
+
+	admitOther := func(p *Policy) error {
+		_, err := p.Admit("eth_sendPrivateTransaction", []interface{}{map[string]interface{}{"tx": other.String()}})
+		return err
+	}
+
+	// A call that times out may have reached the node, its spend stays counted
+	unanswered := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unanswered
+	}))
+	defer server.Close()
+	defer close(unanswered)
+
+	p = New(Rules{MaxSpendPerHour: big.NewInt(100)})
+	client := rpc.NewClient(server.URL, key, rpc.WithHTTPClient(&http.Client{Timeout: 50 * time.Millisecond}), rpc.WithInterceptors(p.Interceptor()))
+	_, err = client.SendPrivateTransaction(tx.String(), &rpc.PrivateTxOptions{})
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrViolation))
+	assert.False(t, errors.Is(err, rpc.ErrNotSent))
+	assert.Equal(t, RuleHourlySpend, violation(t, admitOther(p)).Rule)
+
+	// A call that cannot be signed is released
+	p = New(Rules{MaxSpendPerHour: big.NewInt(100)})
+	client = rpc.NewClient(server.URL, key, rpc.WithInterceptors(p.Interceptor()))
+	_, err = client.CallWithSig("eth_sendPrivateTransaction", map[string]interface{}{"tx": tx.String()}, make(chan int))
+	assert.ErrorIs(t, err, rpc.ErrNotSent)
+	require.NoError(t, admitOther(p))
+
+	// So is a call skipped by a dry run
+	p = New(Rules{MaxSpendPerHour: big.NewInt(100)})
+	client = rpc.NewClient(server.URL, key, rpc.WithDryRun(nil), rpc.WithInterceptors(p.Interceptor()))
+	_, err = client.SendPrivateTransaction(tx.String(), &rpc.PrivateTxOptions{})
+	require.NoError(t, err)
+	require.NoError(t, admitOther(p))
+}
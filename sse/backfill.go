@@ -0,0 +1,214 @@
+package sse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Since is the start of a backfill, by block or by timestamp
+type Since struct {
+	Block     uint64 // First block to backfill, used when set
+	Timestamp uint64 // Unix timestamp to backfill from, used when Block is zero
+}
+
+// backfillOverlap is how long after the last history page was received live events may still repeat backfilled ones,
+// it covers events delivered late by the stream
+const backfillOverlap = time.Minute
+
+// SinceBlock backfills from the block
+func SinceBlock(block uint64) Since {
+	return Since{Block: block}
+}
+
+// SinceTime backfills from the time
+func SinceTime(t time.Time) Since {
+	return Since{Timestamp: uint64(t.Unix())}
+}
+
+// SubscribeWithBackfill subscribes with the client and first sends the events from the history since then,
+// followed by the live events. Events sent during the backfill are not sent again by the live stream.
+// Backfilled events are stamped with the time their history page was received.
+// The live stream is subscribed before the history is queried so no event falls into the gap.
+// A failed backfill is sent as an error event and the live events follow.
+// The event channel is closed once the live stream ends, ctx is done or the subscription is stopped.
+func SubscribeWithBackfill(ctx context.Context, client SSEClient, since Since, eventChan chan<- Event) (SSESubscription, error) {
+	return subscribeWithBackfill(ctx, client, since, eventChan, nil)
+}
+
+// SubscribeWithBackfill is SubscribeWithBackfill using the client, backfilled events also go through its filter
+func (c *InternalClient) SubscribeWithBackfill(ctx context.Context, since Since, eventChan chan<- Event) (SSESubscription, error) {
+	return subscribeWithBackfill(ctx, c, since, eventChan, c.filter)
+}
+
+func subscribeWithBackfill(ctx context.Context, client SSEClient, since Since, eventChan chan<- Event, filter Filter) (SSESubscription, error) {
+	live := make(chan Event, DefaultBufferSize)
+	upstream, err := client.Subscribe(live)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &stageSubscription{
+		upstream: upstream,
+		stopper:  make(chan struct{}),
+	}
+
+	go runBackfill(ctx, sub, client, since, live, eventChan, filter)
+
+	return sub, nil
+}
+
+// runBackfill sends the backfilled events and then the live ones
+func runBackfill(ctx context.Context, s *stageSubscription, client SSEClient, since Since, live <-chan Event, eventChan chan<- Event, filter Filter) {
+	defer close(eventChan)
+	defer s.Stop()
+
+	send := func(event Event) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-s.stopper:
+			return false
+		case eventChan <- event:
+			return true
+		}
+	}
+
+	// The backfilled hashes, forgotten once live events are received past the backfill
+	seen := make(map[common.Hash]struct{})
+	var fetched time.Time // When the last history page was received, the history holds nothing newer
+	forward := func(event Event) bool {
+		if seen != nil && event.Data != nil {
+			receivedAt := event.ReceivedAt
+			if receivedAt.IsZero() {
+				receivedAt = time.Now()
+			}
+			if receivedAt.Sub(fetched) > backfillOverlap {
+				seen = nil
+			} else if _, ok := seen[event.Data.Hash]; ok {
+				return true
+			}
+		}
+		return send(event)
+	}
+
+	// Live events are drained into a slice while the history is paged through,
+	// a long backfill does not stall the stream and its ping watchdog
+	done := make(chan struct{})
+	spilled := spill(live, done)
+
+	err := backfill(ctx, client, since, func(history EventHistory, receivedAt time.Time) bool {
+		fetched = receivedAt
+		hint := history.Hint
+		if _, ok := seen[hint.Hash]; ok || !filter.Match(&hint) {
+			return true
+		}
+		seen[hint.Hash] = struct{}{}
+
+		event := Event{Data: &hint, ReceivedAt: receivedAt}
+		if history.Timestamp != 0 {
+			event.NodeTime = time.Unix(int64(history.Timestamp), 0)
+		}
+		return send(event)
+	})
+	close(done)
+	queued := <-spilled
+	if err != nil && !send(Event{Error: fmt.Errorf("backfill: %w", err)}) {
+		return
+	}
+
+	for _, event := range queued.events {
+		if !forward(event) {
+			return
+		}
+	}
+	if queued.closed {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopper:
+			return
+		case event, ok := <-live:
+			if !ok || !forward(event) {
+				return
+			}
+		}
+	}
+}
+
+// spilledEvents are the live events received during a backfill
+type spilledEvents struct {
+	events []Event
+	closed bool // The live channel was closed
+}
+
+// spill receives live events without a bound until done is closed or live is closed,
+// and then sends what it received
+func spill(live <-chan Event, done <-chan struct{}) <-chan spilledEvents {
+	spilled := make(chan spilledEvents, 1)
+
+	go func() {
+		var queued spilledEvents
+		defer func() { spilled <- queued }()
+
+		for {
+			select {
+			case <-done:
+				return
+			case event, ok := <-live:
+				if !ok {
+					queued.closed = true
+					return
+				}
+				queued.events = append(queued.events, event)
+			}
+		}
+	}()
+
+	return spilled
+}
+
+// backfill pages through the history since then and calls fn for every event, with the time its page was received,
+// until it returns false
+func backfill(ctx context.Context, client SSEClient, since Since, fn func(EventHistory, time.Time) bool) error {
+	info, err := client.EventHistoryInfo()
+	if err != nil {
+		return err
+	}
+
+	params := EventHistoryParams{Limit: info.MaxLimit}
+	if since.Block != 0 {
+		params.BlockStart = since.Block
+	} else {
+		params.TimestampStart = since.Timestamp
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page, err := client.GetEventHistory(params)
+		if err != nil {
+			return err
+		}
+		receivedAt := time.Now()
+
+		for _, history := range page {
+			if !fn(history, receivedAt) {
+				return nil
+			}
+		}
+
+		if len(page) == 0 || (info.MaxLimit != 0 && uint64(len(page)) < info.MaxLimit) {
+			return nil
+		}
+		params.OffSet += uint64(len(page))
+	}
+}
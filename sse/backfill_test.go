@@ -0,0 +1,176 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// historyClient is a fakeClient serving history pages of two events
+type historyClient struct {
+	fakeClient
+	history []EventHistory
+	params  []EventHistoryParams
+	err     error
+}
+
+func (h *historyClient) EventHistoryInfo() (*EventHistoryInfo, error) {
+	return &EventHistoryInfo{MaxLimit: 2}, nil
+}
+
+func (h *historyClient) GetEventHistory(params EventHistoryParams) ([]EventHistory, error) {
+	h.params = append(h.params, params)
+	if h.err != nil {
+		return nil, h.err
+	}
+
+	page := make([]EventHistory, 0)
+	for _, e := range h.history {
+		if e.Block >= params.BlockStart {
+			page = append(page, e)
+		}
+	}
+	if params.OffSet >= uint64(len(page)) {
+		return nil, nil
+	}
+	page = page[params.OffSet:]
+	if uint64(len(page)) > params.Limit {
+		page = page[:params.Limit]
+	}
+	return page, nil
+}
+
+func TestSubscribeWithBackfill(t *testing.T) {
+	client := &historyClient{}
+	for i := byte(1); i <= 5; i++ {
+		client.history = append(client.history, EventHistory{Block: uint64(i), Hint: MatchMakerEvent{Hash: common.BytesToHash([]byte{i})}})
+	}
+
+	events := make(chan Event)
+	sub, err := SubscribeWithBackfill(context.Background(), client, SinceBlock(3), events)
+	require.NoError(t, err)
+	require.True(t, client.subscribed())
+
+	go client.push(5, 6)
+
+	var hashes []common.Hash
+	for len(hashes) < 3 {
+		event := receive(t, events)
+		require.NoError(t, event.Error)
+		hashes = append(hashes, event.Data.Hash)
+	}
+	// 5 was backfilled, the live copy is dropped
+	event := receive(t, events)
+	assert.Equal(t, common.BytesToHash([]byte{6}), event.Data.Hash)
+	assert.Equal(t, []common.Hash{common.BytesToHash([]byte{3}), common.BytesToHash([]byte{4}), common.BytesToHash([]byte{5})}, hashes)
+
+	assert.Equal(t, EventHistoryParams{BlockStart: 3, Limit: 2}, client.params[0])
+	assert.Equal(t, uint64(2), client.params[1].OffSet)
+	assert.Len(t, client.params, 2)
+
+	sub.Stop()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestSubscribeWithBackfill_ForgetsSeen(t *testing.T) {
+	client := &historyClient{}
+	for i := byte(1); i <= 2; i++ {
+		client.history = append(client.history, EventHistory{Block: uint64(i), Hint: MatchMakerEvent{Hash: common.BytesToHash([]byte{i})}})
+	}
+
+	events := make(chan Event)
+	sub, err := SubscribeWithBackfill(context.Background(), client, SinceBlock(1), events)
+	require.NoError(t, err)
+	defer sub.Stop()
+
+	started := time.Now()
+	for i := byte(1); i <= 2; i++ {
+		event := receive(t, events)
+		assert.Equal(t, common.BytesToHash([]byte{i}), event.Data.Hash)
+		assert.False(t, event.ReceivedAt.Before(started))
+	}
+
+	live := func(b byte, receivedAt time.Time) {
+		client.send(Event{Data: &MatchMakerEvent{Hash: common.BytesToHash([]byte{b})}, ReceivedAt: receivedAt})
+	}
+	go func() {
+		live(2, time.Now())
+		live(3, time.Now().Add(2*backfillOverlap))
+		live(2, time.Now().Add(2*backfillOverlap))
+	}()
+
+	// The live copy of 2 is dropped until the stream is past the backfill, then the hashes are forgotten
+	assert.Equal(t, common.BytesToHash([]byte{3}), receive(t, events).Data.Hash)
+	assert.Equal(t, common.BytesToHash([]byte{2}), receive(t, events).Data.Hash)
+}
+
+func TestSubscribeWithBackfill_Paging(t *testing.T) {
+	client := &historyClient{}
+	for i := byte(1); i <= 7; i++ {
+		client.history = append(client.history, EventHistory{Block: uint64(i), Hint: MatchMakerEvent{Hash: common.BytesToHash([]byte{i})}})
+	}
+
+	events := make(chan Event)
+	sub, err := SubscribeWithBackfill(context.Background(), client, SinceBlock(1), events)
+	require.NoError(t, err)
+	require.True(t, client.subscribed())
+
+	// The backfill waits for the consumer, the live stream is still drained
+	live := 2 * DefaultBufferSize
+	pushed := make(chan struct{})
+	go func() {
+		defer close(pushed)
+		for i := 0; i < live; i++ {
+			client.send(Event{Data: &MatchMakerEvent{Hash: common.BigToHash(big.NewInt(int64(100 + i)))}})
+		}
+	}()
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("Live stream stalled during the backfill")
+	}
+
+	for i := byte(1); i <= 7; i++ {
+		assert.Equal(t, common.BytesToHash([]byte{i}), receive(t, events).Data.Hash)
+	}
+	for i := 0; i < live; i++ {
+		assert.Equal(t, common.BigToHash(big.NewInt(int64(100+i))), receive(t, events).Data.Hash)
+	}
+
+	var offsets []uint64
+	for _, params := range client.params {
+		offsets = append(offsets, params.OffSet)
+	}
+	assert.Equal(t, []uint64{0, 2, 4, 6}, offsets)
+
+	sub.Stop()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestSubscribeWithBackfill_Error(t *testing.T) {
+	client := &historyClient{err: errors.New("unavailable")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan Event)
+	_, err := SubscribeWithBackfill(ctx, client, SinceTime(time.Unix(100, 0)), events)
+	require.NoError(t, err)
+
+	event := receive(t, events)
+	assert.ErrorContains(t, event.Error, "backfill: unavailable")
+	assert.Equal(t, uint64(100), client.params[0].TimestampStart)
+
+	go client.push(1)
+	assert.Equal(t, common.BytesToHash([]byte{1}), receive(t, events).Data.Hash)
+
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+}
@@ -0,0 +1,291 @@
+package sse
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBufferSize is the event buffer of the upstream subscription and of every subscriber
+const DefaultBufferSize = 256
+
+// ErrBrokerStarted is returned by Start once the broker was started
+var ErrBrokerStarted = errors.New("broker already started")
+
+// ErrBrokerStopped is returned by Start once the broker was stopped
+var ErrBrokerStopped = errors.New("broker stopped")
+
+// OverflowPolicy decides what happens when a subscriber buffer is full
+type OverflowPolicy int
+
+const (
+	// Block waits until the subscriber has room, slowing down every other subscriber
+	Block OverflowPolicy = iota
+	// DropNewest discards the event that does not fit
+	DropNewest
+	// DropOldest discards the oldest buffered event to make room
+	DropOldest
+)
+
+// Broker shares a single upstream subscription between many subscribers
+type Broker struct {
+	client   SSEClient
+	upstream SSESubscription
+
+	mu          sync.RWMutex
+	subscribers map[*Subscriber]struct{}
+	started     bool
+	stopped     bool
+}
+
+// Subscriber receives the events of a broker
+type Subscriber struct {
+	broker     *Broker
+	events     chan Event
+	done       chan struct{}
+	bufferSize int
+	policy     OverflowPolicy
+	filter     Filter
+	dropped    uint64
+	once       sync.Once
+
+	mu     sync.Mutex // Held while delivering, so events is not closed under a send
+	closed bool
+}
+
+// SubscriberOption configures a Subscriber
+type SubscriberOption func(*Subscriber)
+
+// WithBufferSize sets the number of events buffered for the subscriber
+func WithBufferSize(size int) SubscriberOption {
+	return func(s *Subscriber) {
+		s.bufferSize = size
+	}
+}
+
+// WithOverflowPolicy sets what happens when the subscriber buffer is full
+func WithOverflowPolicy(policy OverflowPolicy) SubscriberOption {
+	return func(s *Subscriber) {
+		s.policy = policy
+	}
+}
+
+// WithSubscriberFilter only delivers events matching the filter, errors are always delivered
+func WithSubscriberFilter(filter Filter) SubscriberOption {
+	return func(s *Subscriber) {
+		s.filter = filter
+	}
+}
+
+// NewBroker creates a broker on top of the client, Start opens the upstream subscription
+func NewBroker(client SSEClient) *Broker {
+	return &Broker{
+		client:      client,
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Start subscribes to the upstream client and starts fanning out its events.
+// A broker is started once, later calls return ErrBrokerStarted, or ErrBrokerStopped once it was stopped.
+func (b *Broker) Start() error {
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		return ErrBrokerStopped
+	}
+	if b.started {
+		b.mu.Unlock()
+		return ErrBrokerStarted
+	}
+	b.started = true
+	b.mu.Unlock()
+
+	events := make(chan Event, DefaultBufferSize)
+
+	sub, err := b.client.Subscribe(events)
+
+	b.mu.Lock()
+	if err != nil {
+		b.started = false
+		b.mu.Unlock()
+		return err
+	}
+	if b.stopped {
+		// Stopped while subscribing
+		b.mu.Unlock()
+		sub.Stop()
+		return ErrBrokerStopped
+	}
+	b.upstream = sub
+	b.mu.Unlock()
+
+	go b.run(events)
+
+	return nil
+}
+
+// Stop stops the upstream subscription, every subscriber channel gets closed.
+// A broker that is not running, before Start or after a failed one, closes its subscribers right away
+// and cannot be started anymore.
+func (b *Broker) Stop() {
+	b.mu.Lock()
+	upstream := b.upstream
+	if upstream == nil {
+		b.stop()
+	}
+	b.mu.Unlock()
+
+	if upstream != nil {
+		upstream.Stop()
+	}
+}
+
+// Subscribe adds a new subscriber receiving every upstream event from now on
+func (b *Broker) Subscribe(opts ...SubscriberOption) *Subscriber {
+	s := &Subscriber{
+		broker:     b,
+		done:       make(chan struct{}),
+		bufferSize: DefaultBufferSize,
+		policy:     Block,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.events = make(chan Event, s.bufferSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopped {
+		s.close()
+		return s
+	}
+	b.subscribers[s] = struct{}{}
+
+	return s
+}
+
+// Subscribers returns the number of active subscribers
+func (b *Broker) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subscribers)
+}
+
+// run fans out upstream events until the upstream channel is closed.
+// The subscribers are delivered to outside the lock, a slow one does not hold up Subscribe and Unsubscribe.
+func (b *Broker) run(events <-chan Event) {
+	var subscribers []*Subscriber
+	for event := range events {
+		b.mu.RLock()
+		subscribers = subscribers[:0]
+		for s := range b.subscribers {
+			subscribers = append(subscribers, s)
+		}
+		b.mu.RUnlock()
+
+		for _, s := range subscribers {
+			s.deliver(event)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stop()
+}
+
+// stop marks the broker stopped and closes the subscribers, b.mu must be held
+func (b *Broker) stop() {
+	b.stopped = true
+	for s := range b.subscribers {
+		delete(b.subscribers, s)
+		s.close()
+	}
+}
+
+// Events returns the channel the subscriber receives events on, it is closed on Unsubscribe
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events dropped because the subscriber buffer was full
+func (s *Subscriber) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Unsubscribe removes the subscriber from the broker and closes its channel
+func (s *Subscriber) Unsubscribe() {
+	s.broker.mu.Lock()
+	delete(s.broker.subscribers, s)
+	s.broker.mu.Unlock()
+
+	s.close()
+}
+
+// close unblocks a pending delivery, waits for it to finish and closes the channel
+func (s *Subscriber) close() {
+	s.closeDone()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+}
+
+// closeDone marks the subscriber as done
+func (s *Subscriber) closeDone() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+// deliver sends the event according to the overflow policy
+func (s *Subscriber) deliver(event Event) {
+	if event.Data != nil && !s.filter.Match(event.Data) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	switch s.policy {
+	case DropNewest:
+		select {
+		case s.events <- event:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+
+	case DropOldest:
+		for {
+			select {
+			case <-s.done:
+				return
+			case s.events <- event:
+				return
+			default:
+			}
+
+			select {
+			case <-s.events:
+				atomic.AddUint64(&s.dropped, 1)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case <-s.done:
+		case s.events <- event:
+		}
+	}
+}
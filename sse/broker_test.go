@@ -0,0 +1,190 @@
+package sse
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient is an SSEClient whose subscription events are pushed by the test
+type fakeClient struct {
+	mu        sync.Mutex
+	eventChan chan<- Event
+	stopped   bool
+}
+
+func (f *fakeClient) Subscribe(eventChan chan<- Event) (SSESubscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.eventChan = eventChan
+	f.stopped = false
+	return f, nil
+}
+
+func (f *fakeClient) EventHistoryInfo() (*EventHistoryInfo, error) {
+	return &EventHistoryInfo{}, nil
+}
+
+func (f *fakeClient) GetEventHistory(EventHistoryParams) ([]EventHistory, error) {
+	return nil, nil
+}
+
+func (f *fakeClient) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.stopped {
+		f.stopped = true
+		close(f.eventChan)
+	}
+}
+
+func (f *fakeClient) subscribed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.eventChan != nil
+}
+
+func (f *fakeClient) send(event Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.stopped {
+		f.eventChan <- event
+	}
+}
+
+func (f *fakeClient) push(hashes ...byte) {
+	for _, h := range hashes {
+		f.send(Event{Data: &MatchMakerEvent{Hash: common.BytesToHash([]byte{h})}})
+	}
+}
+
+func receive(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestBroker_FanOut(t *testing.T) {
+	client := &fakeClient{}
+	broker := NewBroker(client)
+	require.NoError(t, broker.Start())
+
+	first := broker.Subscribe()
+	second := broker.Subscribe()
+	assert.Equal(t, 2, broker.Subscribers())
+
+	client.push(1)
+	assert.Equal(t, common.BytesToHash([]byte{1}), receive(t, first.Events()).Data.Hash)
+	assert.Equal(t, common.BytesToHash([]byte{1}), receive(t, second.Events()).Data.Hash)
+
+	second.Unsubscribe()
+	_, ok := <-second.Events()
+	assert.False(t, ok)
+	assert.Equal(t, 1, broker.Subscribers())
+
+	client.push(2)
+	assert.Equal(t, common.BytesToHash([]byte{2}), receive(t, first.Events()).Data.Hash)
+
+	broker.Stop()
+	_, ok = <-first.Events()
+	assert.False(t, ok)
+
+	_, ok = <-broker.Subscribe().Events()
+	assert.False(t, ok)
+}
+
+func TestBroker_OverflowPolicies(t *testing.T) {
+	client := &fakeClient{}
+	broker := NewBroker(client)
+	require.NoError(t, broker.Start())
+
+	newest := broker.Subscribe(WithBufferSize(2), WithOverflowPolicy(DropNewest))
+	oldest := broker.Subscribe(WithBufferSize(2), WithOverflowPolicy(DropOldest))
+	blocking := broker.Subscribe(WithBufferSize(4))
+
+	client.push(1, 2, 3, 4)
+	for i := byte(1); i <= 4; i++ {
+		assert.Equal(t, common.BytesToHash([]byte{i}), receive(t, blocking.Events()).Data.Hash)
+	}
+
+	assert.Equal(t, uint64(2), newest.Dropped())
+	assert.Equal(t, common.BytesToHash([]byte{1}), receive(t, newest.Events()).Data.Hash)
+	assert.Equal(t, common.BytesToHash([]byte{2}), receive(t, newest.Events()).Data.Hash)
+
+	assert.Equal(t, uint64(2), oldest.Dropped())
+	assert.Equal(t, common.BytesToHash([]byte{3}), receive(t, oldest.Events()).Data.Hash)
+	assert.Equal(t, common.BytesToHash([]byte{4}), receive(t, oldest.Events()).Data.Hash)
+
+	assert.Equal(t, uint64(0), blocking.Dropped())
+
+	broker.Stop()
+}
+
+func TestBroker_SlowSubscriber(t *testing.T) {
+	client := &fakeClient{}
+	broker := NewBroker(client)
+	require.NoError(t, broker.Start())
+	defer broker.Stop()
+
+	slow := broker.Subscribe(WithBufferSize(1))
+	client.push(1, 2)
+	assert.Eventually(t, func() bool { return len(slow.Events()) == 1 }, time.Second, 5*time.Millisecond)
+
+	// The fan-out is blocked on the slow subscriber, the broker is not
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		other := broker.Subscribe()
+		assert.Equal(t, 2, broker.Subscribers())
+		other.Unsubscribe()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Broker blocked by a slow subscriber")
+	}
+
+	slow.Unsubscribe()
+	assert.Equal(t, 0, broker.Subscribers())
+}
+
+func TestBroker_StartTwice(t *testing.T) {
+	client := &fakeClient{}
+	broker := NewBroker(client)
+	require.NoError(t, broker.Start())
+	defer broker.Stop()
+
+	assert.ErrorIs(t, broker.Start(), ErrBrokerStarted)
+}
+
+func TestBroker_StopBeforeStart(t *testing.T) {
+	client := &fakeClient{}
+	broker := NewBroker(client)
+	sub := broker.Subscribe()
+
+	broker.Stop()
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.Zero(t, broker.Subscribers())
+
+	// Stopped for good, later subscribers are closed right away
+	assert.ErrorIs(t, broker.Start(), ErrBrokerStopped)
+	assert.False(t, client.subscribed())
+	_, ok = <-broker.Subscribe().Events()
+	assert.False(t, ok)
+}
@@ -2,89 +2,440 @@ package sse
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/internal/logging"
+	"github.com/duoxehyon/mev-share-go/tracing"
 )
 
 // InternalClient is a client for the matchmaker
 type InternalClient struct {
 	BaseURL string // BaseURL is the base URL for the matchmaker
+
+	httpClient *http.Client
+	filter     Filter
+	mode       DecodeMode
+
+	pingTimeout    time.Duration
+	reconnect      bool
+	reconnectDelay time.Duration
+	trackLatency   bool
+	head           HeadSource
+	metrics        Metrics
+	tracer         tracing.Tracer
+	logger         *slog.Logger
+}
+
+// Option configures an InternalClient
+type Option func(*InternalClient)
+
+// WithHTTPClient sets the http client used for the stream and history requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *InternalClient) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithFilter only delivers events matching the filter, errors are always delivered
+func WithFilter(filter Filter) Option {
+	return func(c *InternalClient) {
+		c.filter = filter
+	}
+}
+
+// WithDecodeMode sets how malformed hint fields are handled, defaults to DefaultDecodeMode
+func WithDecodeMode(mode DecodeMode) Option {
+	return func(c *InternalClient) {
+		c.mode = mode
+	}
+}
+
+// WithPingTimeout declares a stream stalled when neither a ping nor an event arrives within the timeout.
+// A stalled stream is closed and an ErrStalled error event is sent. 0, the default, disables the check.
+func WithPingTimeout(timeout time.Duration) Option {
+	return func(c *InternalClient) {
+		c.pingTimeout = timeout
+	}
+}
+
+// WithReconnect reopens streams that stalled or ended after the delay,
+// the event channel is then only closed once the subscription is stopped
+func WithReconnect(delay time.Duration) Option {
+	return func(c *InternalClient) {
+		c.reconnect = true
+		c.reconnectDelay = delay
+	}
+}
+
+// WithLatencyTracking measures how late the events of every subscription are received against the latest block
+// of head, see Subscription.Latency. The live stream carries no node timestamps, use a LatencyTracker to measure
+// backfilled or replayed events against theirs.
+func WithLatencyTracking(head HeadSource) Option {
+	return func(c *InternalClient) {
+		c.trackLatency = true
+		c.head = head
+	}
+}
+
+// WithTracer starts a span for every delivered hint, see Event.Context and the oteltracing package
+func WithTracer(tracer tracing.Tracer) Option {
+	return func(c *InternalClient) {
+		c.tracer = tracer
+	}
+}
+
+// WithLogger logs the connection lifecycle, reconnects, decode failures and history requests, nothing is logged by default
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *InternalClient) {
+		c.logger = logger
+	}
 }
 
 // New creates a new InternalClient for the matchmaker with the given base URL
-func New(baseURL string) SSEClient {
-	return &InternalClient{
+func New(baseURL string, opts ...Option) SSEClient {
+	c := &InternalClient{
 		BaseURL: baseURL,
+		mode:    DefaultDecodeMode,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// client returns the configured http client or the default one
+func (c *InternalClient) client() *http.Client {
+	if c.httpClient != nil {
+		return c.httpClient
+	}
+
+	return http.DefaultClient
+}
+
+// metricsOrNop returns the configured metrics or ones that discard the measurements
+func (c *InternalClient) metricsOrNop() Metrics {
+	if c.metrics != nil {
+		return c.metrics
 	}
+
+	return nopMetrics{}
+}
+
+// log returns the configured logger or one that discards the records
+func (c *InternalClient) log() *slog.Logger {
+	return logging.OrDiscard(c.logger)
 }
 
+// ErrStalled is sent as an error event when a stream receives nothing within the ping timeout
+var ErrStalled = errors.New("stream stalled")
+
 // Subscription represents a subscription to matchmaker events
 type Subscription struct {
-	client    http.Client
+	client    *http.Client
+	url       string
 	stopper   chan struct{}
-	scanner   *bufio.Scanner
+	stopOnce  sync.Once
 	eventChan chan<- Event
+	filter    Filter
+	mode      DecodeMode
+
+	pingTimeout    time.Duration
+	reconnect      bool
+	reconnectDelay time.Duration
+	latency        *LatencyTracker
+	metrics        Metrics
+	tracer         tracing.Tracer
+	logger         *slog.Logger
+
+	mu         sync.Mutex
+	body       io.ReadCloser
+	lastPing   time.Time
+	lastEvent  time.Time
+	reconnects uint64
 }
 
 // Subscribe to matchmaker events and returns a type that can be used to control the subscription
 func (c *InternalClient) Subscribe(eventChan chan<- Event) (SSESubscription, error) {
-	req, err := http.NewRequest("GET", c.BaseURL, nil)
+	sub := &Subscription{
+		client:         c.client(),
+		url:            c.BaseURL,
+		eventChan:      eventChan,
+		filter:         c.filter,
+		mode:           c.mode,
+		pingTimeout:    c.pingTimeout,
+		reconnect:      c.reconnect,
+		reconnectDelay: c.reconnectDelay,
+		metrics:        c.metricsOrNop(),
+		tracer:         c.tracer,
+		logger:         c.log().With("url", c.BaseURL),
+		stopper:        make(chan struct{}),
+	}
+	if c.trackLatency {
+		sub.latency = NewLatencyTracker(c.head)
+	}
+
+	body, err := sub.connect()
 	if err != nil {
+		sub.logger.Warn("subscribe failed", "err", err)
 		return nil, err
 	}
+	sub.body = body
+	sub.logger.Info("subscribed")
+
+	go sub.readEvents(body)
+
+	return sub, nil
+}
+
+// LastPing returns when the last ping was received, zero if none was
+func (s *Subscription) LastPing() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastPing
+}
+
+// LastEvent returns when the last event was received, zero if none was
+func (s *Subscription) LastEvent() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastEvent
+}
+
+// Reconnects returns how many times the stream was reconnected
+func (s *Subscription) Reconnects() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reconnects
+}
+
+// Latency summarizes how late events were received against the latest block, it is empty unless latency tracking is enabled
+func (s *Subscription) Latency() LatencySummary {
+	if s.latency == nil {
+		return LatencySummary{}
+	}
 
-	client := http.Client{}
-	resp, err := client.Do(req)
+	return s.latency.Stats().Block
+}
+
+// connect opens the stream
+func (s *Subscription) connect() (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", s.url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	sub := &Subscription{
-		client:    client,
-		eventChan: eventChan,
-		stopper:   make(chan struct{}),
-		scanner:   bufio.NewScanner(resp.Body),
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
 	}
 
-	go sub.readEvents()
+	return resp.Body, nil
+}
+
+// readEvents reads the events and sends them to the event channel, reconnecting if enabled.
+// The event channel is closed once the stream ends without reconnecting or the subscription is stopped.
+func (s *Subscription) readEvents(body io.ReadCloser) {
+	defer close(s.eventChan)
 
-	return sub, nil
+	for {
+		stalled := s.readStream(body)
+		if s.stopped() {
+			return
+		}
+
+		if stalled {
+			s.logger.Warn("stream stalled", "timeout", s.pingTimeout)
+			err := fmt.Errorf("%w: nothing received for %s", ErrStalled, s.pingTimeout)
+			if !s.send(Event{Error: err, ReceivedAt: time.Now()}) {
+				return
+			}
+		}
+		if !s.reconnect {
+			return
+		}
+
+		if body = s.redial(); body == nil {
+			return
+		}
+	}
 }
 
-// readEvents reads the events and sends them to the event channel
-func (s *Subscription) readEvents() {
-	for s.scanner.Scan() {
-		data := s.scanner.Text()
-		if data == ":ping" || data == "" {
+// readStream reads the events of one connection, it returns true if the stream stalled
+func (s *Subscription) readStream(body io.ReadCloser) bool {
+	var stalled int32
+	alive := func() {}
+	if s.pingTimeout > 0 {
+		watchdog := time.AfterFunc(s.pingTimeout, func() {
+			atomic.StoreInt32(&stalled, 1)
+			body.Close()
+		})
+		defer watchdog.Stop()
+		alive = func() { watchdog.Reset(s.pingTimeout) }
+	}
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data := scanner.Text()
+		if data == "" {
 			continue
 		}
+		receivedAt := time.Now()
+		alive()
+
+		if data == ":ping" {
+			s.mu.Lock()
+			if !s.lastPing.IsZero() {
+				s.metrics.PingGap(receivedAt.Sub(s.lastPing))
+			}
+			s.lastPing = receivedAt
+			s.mu.Unlock()
+			continue
+		}
+
+		s.mu.Lock()
+		s.lastEvent = receivedAt
+		s.mu.Unlock()
+		s.metrics.EventReceived()
 
 		data = strings.TrimPrefix(data, "data: ")
 
-		var event MatchMakerEvent
-		err := json.Unmarshal([]byte(data), &event)
+		event, err := DecodeEvent([]byte(data), s.mode)
+		if err != nil {
+			s.metrics.DecodeError()
+			s.logger.Warn("decode failed", "err", err, "size", len(data))
+		} else if len(event.Warnings) > 0 {
+			s.logger.Debug("malformed fields", "hash", event.Hash, "warnings", len(event.Warnings), "first", event.Warnings[0])
+		}
+		if err == nil && !s.filter.Match(event) {
+			continue
+		}
+
+		if s.stopped() {
+			return false
+		}
+
+		next := Event{Data: event, ReceivedAt: receivedAt}
+		if err != nil {
+			next = Event{Error: err, ReceivedAt: receivedAt}
+		}
+		if s.latency != nil {
+			next.Latency, _ = s.latency.Observe(next)
+		}
+
+		if !s.deliver(next) {
+			return false
+		}
+	}
+
+	body.Close()
+	if !s.stopped() {
+		s.logger.Info("stream ended", "err", scanner.Err())
+	}
+	return atomic.LoadInt32(&stalled) == 1
+}
 
+// redial reconnects until it succeeds, it returns nil once the subscription is stopped
+func (s *Subscription) redial() io.ReadCloser {
+	for attempt := 1; ; attempt++ {
+		s.logger.Info("reconnecting", "attempt", attempt, "delay", s.reconnectDelay)
+		timer := time.NewTimer(s.reconnectDelay)
 		select {
 		case <-s.stopper:
-			close(s.eventChan)
-			close(s.stopper)
-			return
-		default:
-			if err != nil {
-				s.eventChan <- Event{
-					Error: err,
-				}
-			} else {
-				s.eventChan <- Event{
-					Data: &event,
-				}
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		body, err := s.connect()
+		if err != nil {
+			s.logger.Warn("reconnect failed", "attempt", attempt, "err", err)
+			if !s.send(Event{Error: fmt.Errorf("reconnect: %w", err), ReceivedAt: time.Now()}) {
+				return nil
 			}
+			continue
+		}
+
+		s.mu.Lock()
+		if s.stopped() {
+			s.mu.Unlock()
+			body.Close()
+			return nil
 		}
+		s.body = body
+		s.reconnects++
+		s.mu.Unlock()
+		s.metrics.Reconnected()
+		s.logger.Info("reconnected", "attempt", attempt)
+
+		return body
+	}
+}
+
+// deliver sends the event, inside a span of the hint when tracing is enabled
+func (s *Subscription) deliver(event Event) bool {
+	if s.tracer == nil || event.Data == nil {
+		return s.send(event)
+	}
+
+	ctx, span := s.tracer.Start(context.Background(), "mevshare.hint", tracing.String(tracing.HintHashKey, event.Data.Hash.Hex()))
+	if event.Latency != 0 {
+		span.SetAttributes(tracing.Int64(tracing.LatencyKey, event.Latency.Milliseconds()))
+	}
+	event.ctx = ctx
+	defer span.End()
+
+	return s.send(event)
+}
+
+// send delivers the event, it returns false once the subscription is stopped
+func (s *Subscription) send(event Event) bool {
+	select {
+	case s.eventChan <- event:
+		s.metrics.Backpressure(0)
+		return true
+	default:
+	}
+
+	start := time.Now()
+	select {
+	case <-s.stopper:
+		return false
+	case s.eventChan <- event:
+		s.metrics.Backpressure(time.Since(start))
+		return true
+	}
+}
+
+func (s *Subscription) stopped() bool {
+	select {
+	case <-s.stopper:
+		return true
+	default:
+		return false
 	}
 }
 
 // Stop stops the subscription to matchmaker events
 func (s *Subscription) Stop() {
-	s.stopper <- struct{}{}
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		close(s.stopper)
+		s.body.Close()
+		s.logger.Debug("subscription stopped")
+	})
 }
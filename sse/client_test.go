@@ -1,12 +1,16 @@
 package sse
 
 import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func createMockServer() *httptest.Server {
@@ -62,3 +66,55 @@ func TestSubscription_Stop(t *testing.T) {
 
 	assert.Equal(t, false, ok)
 }
+
+func TestSubscription_Stalled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(":ping\n\ndata: {\"hash\":\"0x0000000000000000000000000000000000000000000000000000000000000001\"}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	eventChan := make(chan Event, 1)
+	sub, err := New(server.URL, WithPingTimeout(50*time.Millisecond)).Subscribe(eventChan)
+	require.NoError(t, err)
+
+	event := receive(t, eventChan)
+	require.NoError(t, event.Error)
+	subscription := sub.(*Subscription)
+	assert.False(t, subscription.LastPing().IsZero())
+	assert.False(t, subscription.LastEvent().Before(subscription.LastPing()))
+
+	assert.ErrorIs(t, receive(t, eventChan).Error, ErrStalled)
+	_, ok := <-eventChan
+	assert.False(t, ok)
+	assert.Zero(t, subscription.Reconnects())
+}
+
+func TestSubscription_Logger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: {\"hash\":\"0x01\"}\n\n"))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	eventChan := make(chan Event, 1)
+	_, err := New(server.URL, WithLogger(logger), WithDecodeMode(Strict)).Subscribe(eventChan)
+	require.NoError(t, err)
+	for range eventChan {
+	}
+
+	var messages []string
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record struct {
+			Msg string
+			URL string
+		}
+		require.NoError(t, json.Unmarshal(line, &record))
+		assert.Equal(t, server.URL, record.URL)
+		messages = append(messages, record.Msg)
+	}
+	assert.Equal(t, []string{"subscribed", "decode failed", "stream ended"}, messages)
+}
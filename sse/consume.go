@@ -0,0 +1,243 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrSubscriptionClosed is returned once the underlying subscription has ended
+var ErrSubscriptionClosed = errors.New("subscription closed")
+
+// ErrWorkersNotSupported is returned by Iterate when WithWorkers is used, the caller pulls the events itself
+var ErrWorkersNotSupported = errors.New("WithWorkers does not apply to Iterate")
+
+// HandlerFunc handles a single event, returning an error stops the subscription
+type HandlerFunc func(event *MatchMakerEvent) error
+
+// ConsumeOption configures SubscribeFunc and Iterate
+type ConsumeOption func(*consumeConfig)
+
+type consumeConfig struct {
+	workers int // Zero if not set
+	errChan chan<- error
+	dropped *uint64
+}
+
+// WithWorkers runs the handler on up to n events concurrently, events are no longer handled in order.
+// It only applies to SubscribeFunc, Iterate returns ErrWorkersNotSupported.
+func WithWorkers(n int) ConsumeOption {
+	return func(c *consumeConfig) {
+		if n > 0 {
+			c.workers = n
+		}
+	}
+}
+
+// WithErrorChan sets the channel stream errors are sent to, they are dropped when it is full
+func WithErrorChan(errChan chan<- error) ConsumeOption {
+	return func(c *consumeConfig) {
+		c.errChan = errChan
+	}
+}
+
+// WithDroppedErrors adds the errors that were not delivered to n: stream errors without
+// an error channel or room on it, and handler errors after the one SubscribeFunc returns
+func WithDroppedErrors(n *uint64) ConsumeOption {
+	return func(c *consumeConfig) {
+		c.dropped = n
+	}
+}
+
+func newConsumeConfig(opts []ConsumeOption) *consumeConfig {
+	c := &consumeConfig{dropped: new(uint64)}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// sendError forwards a stream error without blocking the stream, it is counted as dropped otherwise
+func (c *consumeConfig) sendError(err error) {
+	if c.errChan == nil {
+		c.drop()
+		return
+	}
+
+	select {
+	case c.errChan <- err:
+	default:
+		c.drop()
+	}
+}
+
+// drop counts an error that was not delivered
+func (c *consumeConfig) drop() {
+	atomic.AddUint64(c.dropped, 1)
+}
+
+// SubscribeFunc subscribes with the client and calls the handler for every event.
+// It blocks until the context is done, the handler returns an error or the stream ends,
+// and returns the handler error, the context error or ErrSubscriptionClosed.
+func SubscribeFunc(ctx context.Context, client SSEClient, handler HandlerFunc, opts ...ConsumeOption) error {
+	cfg := newConsumeConfig(opts)
+
+	events := make(chan Event, DefaultBufferSize)
+	sub, err := client.Subscribe(events)
+	if err != nil {
+		return err
+	}
+	defer sub.Stop()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg         sync.WaitGroup
+		once       sync.Once
+		handlerErr error
+	)
+
+	workers := cfg.workers
+	if workers == 0 {
+		workers = 1
+	}
+
+	jobs := make(chan *MatchMakerEvent)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for event := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := handler(event); err != nil {
+					returned := false
+					once.Do(func() {
+						handlerErr = err
+						returned = true
+						cancel()
+					})
+					if !returned {
+						cfg.drop()
+					}
+				}
+			}
+		}()
+	}
+
+	result := ErrSubscriptionClosed
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			result = ctx.Err()
+			break loop
+		case event, ok := <-events:
+			if !ok {
+				break loop
+			}
+			if event.Error != nil {
+				cfg.sendError(event.Error)
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				result = ctx.Err()
+				break loop
+			case jobs <- event.Data:
+			}
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	return result
+}
+
+// SubscribeFunc subscribes to matchmaker events and calls the handler for every event, see SubscribeFunc
+func (c *InternalClient) SubscribeFunc(ctx context.Context, handler HandlerFunc, opts ...ConsumeOption) error {
+	return SubscribeFunc(ctx, c, handler, opts...)
+}
+
+// Iterator pulls events from a subscription, stream errors are sent to Errors
+type Iterator struct {
+	sub    SSESubscription
+	events chan Event
+	errors chan error
+	cfg    *consumeConfig
+}
+
+// Iterate subscribes with the client and returns an iterator over its events
+func Iterate(client SSEClient, opts ...ConsumeOption) (*Iterator, error) {
+	cfg := newConsumeConfig(opts)
+	if cfg.workers != 0 {
+		return nil, ErrWorkersNotSupported
+	}
+
+	it := &Iterator{
+		events: make(chan Event, DefaultBufferSize),
+		cfg:    cfg,
+	}
+	if cfg.errChan == nil {
+		it.errors = make(chan error, DefaultBufferSize)
+		cfg.errChan = it.errors
+	}
+
+	sub, err := client.Subscribe(it.events)
+	if err != nil {
+		return nil, err
+	}
+	it.sub = sub
+
+	return it, nil
+}
+
+// Iterate subscribes to matchmaker events and returns an iterator over them
+func (c *InternalClient) Iterate(opts ...ConsumeOption) (*Iterator, error) {
+	return Iterate(c, opts...)
+}
+
+// Next blocks until the next event arrives.
+// Returns the context error or ErrSubscriptionClosed once the stream has ended.
+func (it *Iterator) Next(ctx context.Context) (*MatchMakerEvent, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, ok := <-it.events:
+			if !ok {
+				return nil, ErrSubscriptionClosed
+			}
+			if event.Error != nil {
+				it.cfg.sendError(event.Error)
+				continue
+			}
+			return event.Data, nil
+		}
+	}
+}
+
+// Errors returns the channel stream errors are sent to, nil if WithErrorChan was used
+func (it *Iterator) Errors() <-chan error {
+	return it.errors
+}
+
+// Dropped returns the number of stream errors dropped because the error channel was full, see WithDroppedErrors
+func (it *Iterator) Dropped() uint64 {
+	return atomic.LoadUint64(it.cfg.dropped)
+}
+
+// Stop stops the underlying subscription
+func (it *Iterator) Stop() {
+	it.sub.Stop()
+}
@@ -0,0 +1,143 @@
+package sse
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeFunc_HandlerError(t *testing.T) {
+	client := &fakeClient{}
+	errStop := errors.New("stop")
+	errChan := make(chan error, 1)
+
+	done := make(chan error)
+	var handled []common.Hash
+	go func() {
+		done <- SubscribeFunc(context.Background(), client, func(event *MatchMakerEvent) error {
+			handled = append(handled, event.Hash)
+			if len(handled) == 2 {
+				return errStop
+			}
+			return nil
+		}, WithErrorChan(errChan))
+	}()
+
+	require.Eventually(t, client.subscribed, time.Second, time.Millisecond)
+
+	client.push(1)
+	client.send(Event{Error: errors.New("decode error")})
+	client.push(2, 3)
+
+	assert.ErrorIs(t, <-done, errStop)
+	assert.Equal(t, []common.Hash{common.BytesToHash([]byte{1}), common.BytesToHash([]byte{2})}, handled)
+	assert.EqualError(t, <-errChan, "decode error")
+}
+
+func TestSubscribeFunc_Workers(t *testing.T) {
+	client := &fakeClient{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var running, maxRunning int32
+	release := make(chan struct{})
+
+	done := make(chan error)
+	go func() {
+		done <- SubscribeFunc(ctx, client, func(event *MatchMakerEvent) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			<-release
+			atomic.AddInt32(&running, -1)
+			return nil
+		}, WithWorkers(3))
+	}()
+
+	require.Eventually(t, client.subscribed, time.Second, time.Millisecond)
+
+	client.push(1, 2, 3, 4, 5)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&running) == 3 }, time.Second, time.Millisecond)
+	close(release)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+}
+
+func TestIterator(t *testing.T) {
+	client := &fakeClient{}
+	it, err := Iterate(client)
+	require.NoError(t, err)
+
+	client.push(1)
+	client.send(Event{Error: errors.New("decode error")})
+	client.push(2)
+
+	ctx := context.Background()
+
+	event, err := it.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, common.BytesToHash([]byte{1}), event.Hash)
+
+	event, err = it.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, common.BytesToHash([]byte{2}), event.Hash)
+	assert.EqualError(t, <-it.Errors(), "decode error")
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = it.Next(timeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	it.Stop()
+	_, err = it.Next(ctx)
+	assert.ErrorIs(t, err, ErrSubscriptionClosed)
+}
+
+func TestSubscribeFunc_DroppedErrors(t *testing.T) {
+	client := &fakeClient{}
+	errStop := errors.New("stop")
+
+	var dropped uint64
+	done := make(chan error)
+	go func() {
+		done <- SubscribeFunc(context.Background(), client, func(event *MatchMakerEvent) error {
+			return errStop
+		}, WithDroppedErrors(&dropped))
+	}()
+
+	require.Eventually(t, client.subscribed, time.Second, time.Millisecond)
+
+	client.send(Event{Error: errors.New("decode error")})
+	client.push(1)
+
+	assert.ErrorIs(t, <-done, errStop)
+	assert.Equal(t, uint64(1), atomic.LoadUint64(&dropped))
+}
+
+func TestIterator_Options(t *testing.T) {
+	_, err := Iterate(&fakeClient{}, WithWorkers(2))
+	assert.ErrorIs(t, err, ErrWorkersNotSupported)
+
+	client := &fakeClient{}
+	it, err := Iterate(client, WithErrorChan(make(chan error)))
+	require.NoError(t, err)
+	defer it.Stop()
+
+	client.send(Event{Error: errors.New("decode error")})
+	client.push(1)
+
+	_, err = it.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), it.Dropped())
+}
@@ -0,0 +1,301 @@
+package decode
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrUnknownSelector is returned for transactions whose function selector could not be resolved
+var ErrUnknownSelector = errors.New("unknown function selector")
+
+//go:embed signatures.txt
+var signaturesFile string
+
+// signatureDB holds the embedded signatures, keyed by selector
+var signatureDB = mustLoadSignatures(signaturesFile)
+
+// Call is a resolved transaction call
+type Call struct {
+	Selector  [4]byte
+	Signature string                 // Canonical signature, e.g. "transfer(address,uint256)"
+	Method    abi.Method             // Arguments are named arg0, arg1... when resolved from the signature database
+	Args      map[string]interface{} // Decoded arguments, nil when the calldata was not revealed
+}
+
+// Name returns the method name
+func (c *Call) Name() string {
+	return c.Method.RawName
+}
+
+// ABIRegistry resolves function selectors to methods and decodes calldata.
+// Contract ABIs take precedence over generic ABIs, which take precedence over the
+// embedded signature database.
+type ABIRegistry struct {
+	mu        sync.RWMutex
+	methods   map[[4]byte]abi.Method
+	contracts map[common.Address]map[[4]byte]abi.Method
+}
+
+// NewABIRegistry creates a registry backed by the embedded signature database
+func NewABIRegistry() *ABIRegistry {
+	return &ABIRegistry{
+		methods:   make(map[[4]byte]abi.Method),
+		contracts: make(map[common.Address]map[[4]byte]abi.Method),
+	}
+}
+
+// AddABI adds the methods of the ABI, for calls to any contract
+// or only for the given contracts
+func (r *ABIRegistry) AddABI(contractABI abi.ABI, contracts ...common.Address) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(contracts) == 0 {
+		for _, m := range contractABI.Methods {
+			r.methods[selectorOf(m)] = m
+		}
+		return
+	}
+
+	for _, c := range contracts {
+		if r.contracts[c] == nil {
+			r.contracts[c] = make(map[[4]byte]abi.Method)
+		}
+		for _, m := range contractABI.Methods {
+			r.contracts[c][selectorOf(m)] = m
+		}
+	}
+}
+
+// LoadABI reads a JSON ABI and adds it, see AddABI
+func (r *ABIRegistry) LoadABI(reader io.Reader, contracts ...common.Address) error {
+	contractABI, err := abi.JSON(reader)
+	if err != nil {
+		return err
+	}
+
+	r.AddABI(contractABI, contracts...)
+	return nil
+}
+
+// LoadABIFile reads a JSON ABI file and adds it, see AddABI
+func (r *ABIRegistry) LoadABIFile(path string, contracts ...common.Address) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := r.LoadABI(file, contracts...); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Resolve returns the method for a selector called on the contract
+func (r *ABIRegistry) Resolve(contract common.Address, selector [4]byte) (abi.Method, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if m, ok := r.contracts[contract][selector]; ok {
+		return m, true
+	}
+	if m, ok := r.methods[selector]; ok {
+		return m, true
+	}
+
+	m, ok := signatureDB[selector]
+	return m, ok
+}
+
+// DecodeTx resolves the transaction call, arguments are only decoded when the calldata was revealed
+func (r *ABIRegistry) DecodeTx(tx *sse.PendingTransaction) (*Call, error) {
+	selector, ok := txSelector(tx)
+	if !ok {
+		return nil, ErrUnknownSelector
+	}
+
+	method, ok := r.Resolve(tx.To, selector)
+	if !ok {
+		return nil, fmt.Errorf("%w: %#x", ErrUnknownSelector, selector)
+	}
+
+	call := &Call{
+		Selector:  selector,
+		Signature: method.Sig,
+		Method:    method,
+	}
+
+	if len(tx.CallData) >= 4 {
+		args := make(map[string]interface{})
+		if err := method.Inputs.UnpackIntoMap(args, tx.CallData[4:]); err != nil {
+			return nil, fmt.Errorf("%s: %w", method.Sig, err)
+		}
+		call.Args = args
+	}
+
+	return call, nil
+}
+
+// MethodFilter matches events with a transaction calling a method with one of the names,
+// it works on the function selector alone when the calldata was not revealed
+func (r *ABIRegistry) MethodFilter(names ...string) sse.Filter {
+	set := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		set[n] = struct{}{}
+	}
+
+	return func(event *sse.MatchMakerEvent) bool {
+		for i := range event.Txs {
+			selector, ok := txSelector(&event.Txs[i])
+			if !ok {
+				continue
+			}
+			if m, ok := r.Resolve(event.Txs[i].To, selector); ok {
+				if _, ok := set[m.RawName]; ok {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// txSelector returns the selector from the calldata, or the revealed function selector
+func txSelector(tx *sse.PendingTransaction) ([4]byte, bool) {
+	var selector [4]byte
+	if len(tx.CallData) >= 4 {
+		copy(selector[:], tx.CallData[:4])
+		return selector, true
+	}
+
+	return tx.FunctionSelector, tx.FunctionSelector != selector
+}
+
+func selectorOf(m abi.Method) [4]byte {
+	var selector [4]byte
+	copy(selector[:], m.ID)
+	return selector
+}
+
+func mustLoadSignatures(file string) map[[4]byte]abi.Method {
+	db := make(map[[4]byte]abi.Method)
+
+	scanner := bufio.NewScanner(strings.NewReader(file))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m, err := ParseSignature(line)
+		if err != nil {
+			panic(fmt.Sprintf("signatures.txt: %s: %v", line, err))
+		}
+		if _, ok := db[selectorOf(m)]; !ok {
+			db[selectorOf(m)] = m
+		}
+	}
+
+	return db
+}
+
+// ParseSignature builds a method from a signature such as "transfer(address,uint256)".
+// Arguments are named arg0, arg1... and tuple fields field0, field1...
+func ParseSignature(signature string) (abi.Method, error) {
+	open := strings.Index(signature, "(")
+	if open <= 0 || !strings.HasSuffix(signature, ")") {
+		return abi.Method{}, fmt.Errorf("invalid signature %q", signature)
+	}
+	name := signature[:open]
+
+	types, err := splitTypes(signature[open+1 : len(signature)-1])
+	if err != nil {
+		return abi.Method{}, err
+	}
+
+	inputs := make(abi.Arguments, 0, len(types))
+	for i, t := range types {
+		marshaling, err := parseType(t)
+		if err != nil {
+			return abi.Method{}, err
+		}
+
+		typ, err := abi.NewType(marshaling.Type, "", marshaling.Components)
+		if err != nil {
+			return abi.Method{}, err
+		}
+		inputs = append(inputs, abi.Argument{Name: fmt.Sprintf("arg%d", i), Type: typ})
+	}
+
+	return abi.NewMethod(name, name, abi.Function, "nonpayable", false, false, inputs, nil), nil
+}
+
+// parseType converts a canonical type, possibly a tuple, into its ABI JSON form
+func parseType(t string) (abi.ArgumentMarshaling, error) {
+	if !strings.HasPrefix(t, "(") {
+		return abi.ArgumentMarshaling{Type: t}, nil
+	}
+
+	end := strings.LastIndex(t, ")")
+	fields, err := splitTypes(t[1:end])
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+
+	components := make([]abi.ArgumentMarshaling, 0, len(fields))
+	for i, f := range fields {
+		c, err := parseType(f)
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		c.Name = fmt.Sprintf("field%d", i)
+		components = append(components, c)
+	}
+
+	return abi.ArgumentMarshaling{Type: "tuple" + t[end+1:], Components: components}, nil
+}
+
+// splitTypes splits a comma separated type list, ignoring commas inside tuples
+func splitTypes(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	var (
+		types []string
+		depth int
+		start int
+	)
+	for i, c := range list {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in %q", list)
+			}
+		case ',':
+			if depth == 0 {
+				types = append(types, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in %q", list)
+	}
+
+	return append(types, list[start:]), nil
+}
@@ -0,0 +1,92 @@
+package decode
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	transferSelector = [4]byte{0xa9, 0x05, 0x9c, 0xbb}
+	swapV2Selector   = [4]byte{0x38, 0xed, 0x17, 0x39}
+)
+
+func TestABIRegistry_SignatureDB(t *testing.T) {
+	registry := NewABIRegistry()
+
+	call, err := registry.DecodeTx(&sse.PendingTransaction{To: pool, FunctionSelector: swapV2Selector})
+	require.NoError(t, err)
+	assert.Equal(t, "swapExactTokensForTokens", call.Name())
+	assert.Equal(t, "swapExactTokensForTokens(uint256,uint256,address[],address,uint256)", call.Signature)
+	assert.Nil(t, call.Args)
+
+	data, err := call.Method.Inputs.Pack(big.NewInt(1), big.NewInt(2), []common.Address{pool, router}, user, big.NewInt(3))
+	require.NoError(t, err)
+
+	call, err = registry.DecodeTx(&sse.PendingTransaction{To: pool, CallData: append(swapV2Selector[:], data...)})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(2), call.Args["arg1"])
+	assert.Equal(t, []common.Address{pool, router}, call.Args["arg2"])
+	assert.Equal(t, user, call.Args["arg3"])
+
+	_, err = registry.DecodeTx(&sse.PendingTransaction{To: pool, FunctionSelector: [4]byte{1, 2, 3, 4}})
+	assert.ErrorIs(t, err, ErrUnknownSelector)
+	_, err = registry.DecodeTx(&sse.PendingTransaction{To: pool})
+	assert.ErrorIs(t, err, ErrUnknownSelector)
+}
+
+func TestABIRegistry_LoadABIFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[
+			{"name":"recipient","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]}
+	]`), 0o600))
+
+	registry := NewABIRegistry()
+	require.NoError(t, registry.LoadABIFile(path, pool))
+
+	data, err := signatureDB[transferSelector].Inputs.Pack(user, big.NewInt(5))
+	require.NoError(t, err)
+	tx := &sse.PendingTransaction{To: pool, CallData: append(transferSelector[:], data...)}
+
+	call, err := registry.DecodeTx(tx)
+	require.NoError(t, err)
+	assert.Equal(t, user, call.Args["recipient"])
+	assert.Equal(t, big.NewInt(5), call.Args["amount"])
+
+	// Other contracts still resolve through the signature database
+	tx.To = router
+	call, err = registry.DecodeTx(tx)
+	require.NoError(t, err)
+	assert.Equal(t, user, call.Args["arg0"])
+
+	assert.Error(t, registry.LoadABIFile(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestABIRegistry_MethodFilter(t *testing.T) {
+	filter := NewABIRegistry().MethodFilter("transfer", "approve")
+
+	assert.True(t, filter(&sse.MatchMakerEvent{Txs: []sse.PendingTransaction{{FunctionSelector: transferSelector}}}))
+	assert.False(t, filter(&sse.MatchMakerEvent{Txs: []sse.PendingTransaction{{FunctionSelector: swapV2Selector}}}))
+	assert.False(t, filter(&sse.MatchMakerEvent{Txs: []sse.PendingTransaction{{}}}))
+}
+
+func TestParseSignature(t *testing.T) {
+	m, err := ParseSignature("exactInputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))")
+	require.NoError(t, err)
+	assert.Equal(t, "exactInputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))", m.Sig)
+	assert.Equal(t, []byte{0x41, 0x4b, 0xf3, 0x89}, m.ID)
+
+	m, err = ParseSignature("deposit()")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xd0, 0xe3, 0x0d, 0xb0}, m.ID)
+
+	_, err = ParseSignature("broken((address)")
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+// Package decode turns the logs revealed in MEV-Share hints into typed events
+package decode
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/duoxehyon/mev-share-go/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrUnknownEvent is returned for logs no decoder is registered for
+var ErrUnknownEvent = errors.New("unknown event")
+
+// Event is a decoded hint log
+type Event interface {
+	// Kind returns the name of the event, e.g. "UniswapV2Swap"
+	Kind() string
+	// Contract returns the address of the contract that emitted the log
+	Contract() common.Address
+	// IsRedacted reports whether the log data was hidden by the hint
+	IsRedacted() bool
+}
+
+// Meta is embedded in every decoded event
+type Meta struct {
+	Address  common.Address // Contract that emitted the log, the pool for swaps
+	Redacted bool           // The log data was not revealed, amounts are nil
+}
+
+// Contract returns the address of the contract that emitted the log
+func (m Meta) Contract() common.Address {
+	return m.Address
+}
+
+// IsRedacted reports whether the log data was hidden by the hint
+func (m Meta) IsRedacted() bool {
+	return m.Redacted
+}
+
+// Direction of a swap between the two tokens of a pool
+type Direction int
+
+const (
+	// DirectionUnknown is used when the amounts were not revealed
+	DirectionUnknown Direction = iota
+	// ZeroForOne swaps token0 for token1
+	ZeroForOne
+	// OneForZero swaps token1 for token0
+	OneForZero
+)
+
+func (d Direction) String() string {
+	switch d {
+	case ZeroForOne:
+		return "zeroForOne"
+	case OneForZero:
+		return "oneForZero"
+	default:
+		return "unknown"
+	}
+}
+
+// DecodeFunc decodes a log, it returns ErrUnknownEvent if the log is not the event it decodes
+type DecodeFunc func(log types.Log) (Event, error)
+
+// Registry maps the event signature (first topic) of logs to decoders
+type Registry struct {
+	mu       sync.RWMutex
+	decoders map[common.Hash][]DecodeFunc
+}
+
+// NewRegistry creates a registry with the built-in decoders
+func NewRegistry() *Registry {
+	r := &Registry{
+		decoders: make(map[common.Hash][]DecodeFunc),
+	}
+	registerBuiltins(r)
+
+	return r
+}
+
+// Default is the registry used by the package level functions
+var Default = NewRegistry()
+
+// Register adds a decoder for logs with the topic as event signature.
+// Decoders registered later for the same topic are tried first.
+func (r *Registry) Register(topic common.Hash, fn DecodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.decoders[topic] = append([]DecodeFunc{fn}, r.decoders[topic]...)
+}
+
+// Decode decodes a single log
+func (r *Registry) Decode(log types.Log) (Event, error) {
+	if len(log.Topics) == 0 {
+		return nil, ErrUnknownEvent
+	}
+
+	r.mu.RLock()
+	decoders := r.decoders[log.Topics[0]]
+	r.mu.RUnlock()
+
+	for _, fn := range decoders {
+		event, err := fn(log)
+		if errors.Is(err, ErrUnknownEvent) {
+			continue
+		}
+		return event, err
+	}
+
+	return nil, ErrUnknownEvent
+}
+
+// DecodeHint decodes every known log of the hint, unknown logs are skipped.
+// Logs that fail to decode are left out, the events of the others are returned with
+// the per-log errors joined, so a caller can use the partial result.
+func (r *Registry) DecodeHint(hint *sse.MatchMakerEvent) ([]Event, error) {
+	events := make([]Event, 0, len(hint.Logs))
+	var errs []error
+	for i, log := range hint.Logs {
+		event, err := r.Decode(log)
+		if errors.Is(err, ErrUnknownEvent) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("log %d: %w", i, err))
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events, errors.Join(errs...)
+}
+
+// Decode decodes a single log with the default registry
+func Decode(log types.Log) (Event, error) {
+	return Default.Decode(log)
+}
+
+// DecodeHint decodes every known log of the hint with the default registry, see Registry.DecodeHint
+func DecodeHint(hint *sse.MatchMakerEvent) ([]Event, error) {
+	return Default.DecodeHint(hint)
+}
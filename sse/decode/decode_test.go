@@ -0,0 +1,139 @@
+package decode
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/duoxehyon/mev-share-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	pool   = common.HexToAddress("0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc")
+	router = common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+	user   = common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+)
+
+func TestDecode_UniswapV2Swap(t *testing.T) {
+	data, err := uniswapV2SwapEvent.Inputs.NonIndexed().Pack(big.NewInt(100), big.NewInt(0), big.NewInt(0), big.NewInt(42))
+	require.NoError(t, err)
+
+	event, err := Decode(types.Log{
+		Address: pool,
+		Topics:  []common.Hash{UniswapV2SwapTopic, common.BytesToHash(router.Bytes()), common.BytesToHash(user.Bytes())},
+		Data:    data,
+	})
+	require.NoError(t, err)
+
+	swap, ok := event.(*UniswapV2Swap)
+	require.True(t, ok)
+	assert.Equal(t, "UniswapV2Swap", swap.Kind())
+	assert.Equal(t, pool, swap.Contract())
+	assert.False(t, swap.IsRedacted())
+	assert.Equal(t, router, *swap.Sender)
+	assert.Equal(t, user, *swap.To)
+	assert.Equal(t, big.NewInt(100), swap.Amount0In)
+	assert.Equal(t, big.NewInt(42), swap.Amount1Out)
+	assert.Equal(t, ZeroForOne, swap.Direction)
+}
+
+func TestDecode_Redacted(t *testing.T) {
+	event, err := Decode(types.Log{Address: pool, Topics: []common.Hash{UniswapV3SwapTopic}})
+	require.NoError(t, err)
+
+	swap, ok := event.(*UniswapV3Swap)
+	require.True(t, ok)
+	assert.True(t, swap.IsRedacted())
+	assert.Nil(t, swap.Sender)
+	assert.Nil(t, swap.Amount0)
+	assert.Equal(t, DirectionUnknown, swap.Direction)
+}
+
+func TestDecode_UniswapV3Swap(t *testing.T) {
+	data, err := uniswapV3SwapEvent.Inputs.NonIndexed().Pack(
+		big.NewInt(-500), big.NewInt(1000), big.NewInt(1<<40), big.NewInt(123), big.NewInt(-200),
+	)
+	require.NoError(t, err)
+
+	event, err := Decode(types.Log{Address: pool, Topics: []common.Hash{UniswapV3SwapTopic}, Data: data})
+	require.NoError(t, err)
+
+	swap := event.(*UniswapV3Swap)
+	assert.Equal(t, big.NewInt(-500), swap.Amount0)
+	assert.Equal(t, big.NewInt(-200), swap.Tick)
+	assert.Equal(t, OneForZero, swap.Direction)
+}
+
+func TestDecode_OtherBuiltins(t *testing.T) {
+	sync, err := uniswapV2SyncEvent.Inputs.NonIndexed().Pack(big.NewInt(1), big.NewInt(2))
+	require.NoError(t, err)
+	balancer, err := balancerSwapEvent.Inputs.NonIndexed().Pack(big.NewInt(3), big.NewInt(4))
+	require.NoError(t, err)
+	curve, err := curveTokenExchangeEvent.Inputs.NonIndexed().Pack(big.NewInt(0), big.NewInt(5), big.NewInt(1), big.NewInt(6))
+	require.NoError(t, err)
+	transfer, err := erc20TransferEvent.Inputs.NonIndexed().Pack(big.NewInt(7))
+	require.NoError(t, err)
+
+	events, err := DecodeHint(&sse.MatchMakerEvent{Logs: []types.Log{
+		{Address: pool, Topics: []common.Hash{UniswapV2SyncTopic}, Data: sync},
+		{Address: pool, Topics: []common.Hash{BalancerSwapTopic, common.HexToHash("0x01"), common.BytesToHash(user.Bytes())}, Data: balancer},
+		{Address: pool, Topics: []common.Hash{common.HexToHash("0xdead")}},
+		{Address: pool, Topics: []common.Hash{CurveTokenExchangeTopic}, Data: curve},
+		{Address: pool, Topics: []common.Hash{CurveCryptoTokenExchangeTopic}},
+		{Address: pool, Topics: []common.Hash{ERC20TransferTopic}, Data: transfer},
+		// ERC-721 Transfer shares the signature but indexes the token id
+		{Address: pool, Topics: []common.Hash{ERC20TransferTopic, {}, {}, {}}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+
+	assert.Equal(t, big.NewInt(2), events[0].(*UniswapV2Sync).Reserve1)
+
+	swap := events[1].(*BalancerSwap)
+	assert.Equal(t, common.HexToHash("0x01"), *swap.PoolID)
+	assert.Equal(t, user, *swap.TokenIn)
+	assert.Nil(t, swap.TokenOut)
+	assert.Equal(t, big.NewInt(4), swap.AmountOut)
+
+	assert.Equal(t, big.NewInt(6), events[2].(*CurveTokenExchange).TokensBought)
+	assert.True(t, events[3].(*CurveTokenExchange).IsRedacted())
+	assert.Equal(t, big.NewInt(7), events[4].(*ERC20Transfer).Value)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	_, err := Decode(types.Log{Address: pool})
+	assert.ErrorIs(t, err, ErrUnknownEvent)
+
+	transfer, err := erc20TransferEvent.Inputs.NonIndexed().Pack(big.NewInt(7))
+	require.NoError(t, err)
+
+	// The bad logs do not discard the good one
+	events, err := DecodeHint(&sse.MatchMakerEvent{Logs: []types.Log{
+		{Address: pool, Topics: []common.Hash{ERC20TransferTopic}, Data: []byte{0x01}},
+		{Address: pool, Topics: []common.Hash{ERC20TransferTopic}, Data: transfer},
+		{Address: pool, Topics: []common.Hash{UniswapV2SyncTopic}, Data: []byte{0x02}},
+	}})
+	assert.ErrorContains(t, err, "log 0: Transfer")
+	assert.ErrorContains(t, err, "log 2: Sync")
+	require.Len(t, events, 1)
+	assert.Equal(t, big.NewInt(7), events[0].(*ERC20Transfer).Value)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	topic := common.HexToHash("0xbeef")
+
+	registry.Register(topic, func(log types.Log) (Event, error) {
+		return &ERC20Transfer{Meta: Meta{Address: log.Address}}, nil
+	})
+
+	event, err := registry.Decode(types.Log{Address: pool, Topics: []common.Hash{topic}})
+	require.NoError(t, err)
+	assert.Equal(t, pool, event.Contract())
+
+	_, err = Default.Decode(types.Log{Address: pool, Topics: []common.Hash{topic}})
+	assert.ErrorIs(t, err, ErrUnknownEvent)
+}
@@ -0,0 +1,364 @@
+package decode
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/duoxehyon/mev-share-go/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Built-in event ABIs, one per protocol as some share event names
+const (
+	uniswapV2ABI = `[
+		{"anonymous":false,"name":"Swap","type":"event","inputs":[
+			{"indexed":true,"name":"sender","type":"address"},
+			{"indexed":false,"name":"amount0In","type":"uint256"},
+			{"indexed":false,"name":"amount1In","type":"uint256"},
+			{"indexed":false,"name":"amount0Out","type":"uint256"},
+			{"indexed":false,"name":"amount1Out","type":"uint256"},
+			{"indexed":true,"name":"to","type":"address"}]},
+		{"anonymous":false,"name":"Sync","type":"event","inputs":[
+			{"indexed":false,"name":"reserve0","type":"uint112"},
+			{"indexed":false,"name":"reserve1","type":"uint112"}]}
+	]`
+	uniswapV3ABI = `[
+		{"anonymous":false,"name":"Swap","type":"event","inputs":[
+			{"indexed":true,"name":"sender","type":"address"},
+			{"indexed":true,"name":"recipient","type":"address"},
+			{"indexed":false,"name":"amount0","type":"int256"},
+			{"indexed":false,"name":"amount1","type":"int256"},
+			{"indexed":false,"name":"sqrtPriceX96","type":"uint160"},
+			{"indexed":false,"name":"liquidity","type":"uint128"},
+			{"indexed":false,"name":"tick","type":"int24"}]}
+	]`
+	balancerABI = `[
+		{"anonymous":false,"name":"Swap","type":"event","inputs":[
+			{"indexed":true,"name":"poolId","type":"bytes32"},
+			{"indexed":true,"name":"tokenIn","type":"address"},
+			{"indexed":true,"name":"tokenOut","type":"address"},
+			{"indexed":false,"name":"amountIn","type":"uint256"},
+			{"indexed":false,"name":"amountOut","type":"uint256"}]}
+	]`
+	curveABI = `[
+		{"anonymous":false,"name":"TokenExchange","type":"event","inputs":[
+			{"indexed":true,"name":"buyer","type":"address"},
+			{"indexed":false,"name":"sold_id","type":"int128"},
+			{"indexed":false,"name":"tokens_sold","type":"uint256"},
+			{"indexed":false,"name":"bought_id","type":"int128"},
+			{"indexed":false,"name":"tokens_bought","type":"uint256"}]}
+	]`
+	curveCryptoABI = `[
+		{"anonymous":false,"name":"TokenExchange","type":"event","inputs":[
+			{"indexed":true,"name":"buyer","type":"address"},
+			{"indexed":false,"name":"sold_id","type":"uint256"},
+			{"indexed":false,"name":"tokens_sold","type":"uint256"},
+			{"indexed":false,"name":"bought_id","type":"uint256"},
+			{"indexed":false,"name":"tokens_bought","type":"uint256"}]}
+	]`
+	erc20ABI = `[
+		{"anonymous":false,"name":"Transfer","type":"event","inputs":[
+			{"indexed":true,"name":"from","type":"address"},
+			{"indexed":true,"name":"to","type":"address"},
+			{"indexed":false,"name":"value","type":"uint256"}]}
+	]`
+)
+
+var (
+	uniswapV2SwapEvent      = mustEvent(uniswapV2ABI, "Swap")
+	uniswapV2SyncEvent      = mustEvent(uniswapV2ABI, "Sync")
+	uniswapV3SwapEvent      = mustEvent(uniswapV3ABI, "Swap")
+	balancerSwapEvent       = mustEvent(balancerABI, "Swap")
+	curveTokenExchangeEvent = mustEvent(curveABI, "TokenExchange")
+	curveCryptoExchange     = mustEvent(curveCryptoABI, "TokenExchange")
+	erc20TransferEvent      = mustEvent(erc20ABI, "Transfer")
+)
+
+// Event signatures of the built-in events
+var (
+	UniswapV2SwapTopic            = uniswapV2SwapEvent.ID
+	UniswapV2SyncTopic            = uniswapV2SyncEvent.ID
+	UniswapV3SwapTopic            = uniswapV3SwapEvent.ID
+	BalancerSwapTopic             = balancerSwapEvent.ID
+	CurveTokenExchangeTopic       = curveTokenExchangeEvent.ID
+	CurveCryptoTokenExchangeTopic = curveCryptoExchange.ID
+	ERC20TransferTopic            = erc20TransferEvent.ID
+)
+
+func mustEvent(abiJSON, name string) abi.Event {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed.Events[name]
+}
+
+func registerBuiltins(r *Registry) {
+	r.Register(UniswapV2SwapTopic, decodeUniswapV2Swap)
+	r.Register(UniswapV2SyncTopic, decodeUniswapV2Sync)
+	r.Register(UniswapV3SwapTopic, decodeUniswapV3Swap)
+	r.Register(BalancerSwapTopic, decodeBalancerSwap)
+	r.Register(CurveTokenExchangeTopic, decodeCurveTokenExchange)
+	r.Register(CurveCryptoTokenExchangeTopic, decodeCurveTokenExchange)
+	r.Register(ERC20TransferTopic, decodeERC20Transfer)
+}
+
+// UniswapV2Swap is the `Swap` event of Uniswap V2 pairs and their forks
+type UniswapV2Swap struct {
+	Meta
+	Sender     *common.Address
+	To         *common.Address
+	Amount0In  *big.Int
+	Amount1In  *big.Int
+	Amount0Out *big.Int
+	Amount1Out *big.Int
+	Direction  Direction
+}
+
+// Kind implements Event
+func (*UniswapV2Swap) Kind() string { return "UniswapV2Swap" }
+
+// UniswapV2Sync is the `Sync` event of Uniswap V2 pairs, emitted with the new reserves
+type UniswapV2Sync struct {
+	Meta
+	Reserve0 *big.Int
+	Reserve1 *big.Int
+}
+
+// Kind implements Event
+func (*UniswapV2Sync) Kind() string { return "UniswapV2Sync" }
+
+// UniswapV3Swap is the `Swap` event of Uniswap V3 pools.
+// Amounts are the pool balance deltas, positive amounts were paid to the pool.
+type UniswapV3Swap struct {
+	Meta
+	Sender       *common.Address
+	Recipient    *common.Address
+	Amount0      *big.Int
+	Amount1      *big.Int
+	SqrtPriceX96 *big.Int
+	Liquidity    *big.Int
+	Tick         *big.Int
+	Direction    Direction
+}
+
+// Kind implements Event
+func (*UniswapV3Swap) Kind() string { return "UniswapV3Swap" }
+
+// BalancerSwap is the `Swap` event of the Balancer V2 vault, Address is the vault
+type BalancerSwap struct {
+	Meta
+	PoolID    *common.Hash
+	TokenIn   *common.Address
+	TokenOut  *common.Address
+	AmountIn  *big.Int
+	AmountOut *big.Int
+}
+
+// Kind implements Event
+func (*BalancerSwap) Kind() string { return "BalancerSwap" }
+
+// CurveTokenExchange is the `TokenExchange` event of Curve pools, ids index the pool coins
+type CurveTokenExchange struct {
+	Meta
+	Buyer        *common.Address
+	SoldID       *big.Int
+	TokensSold   *big.Int
+	BoughtID     *big.Int
+	TokensBought *big.Int
+}
+
+// Kind implements Event
+func (*CurveTokenExchange) Kind() string { return "CurveTokenExchange" }
+
+// ERC20Transfer is the `Transfer` event of ERC-20 tokens, Address is the token
+type ERC20Transfer struct {
+	Meta
+	From  *common.Address
+	To    *common.Address
+	Value *big.Int
+}
+
+// Kind implements Event
+func (*ERC20Transfer) Kind() string { return "ERC20Transfer" }
+
+func decodeUniswapV2Swap(log types.Log) (Event, error) {
+	values, meta, err := unpack(uniswapV2SwapEvent, log)
+	if err != nil {
+		return nil, err
+	}
+
+	swap := &UniswapV2Swap{
+		Meta:   meta,
+		Sender: topicAddress(log, 1),
+		To:     topicAddress(log, 2),
+	}
+	if values != nil {
+		swap.Amount0In = values[0].(*big.Int)
+		swap.Amount1In = values[1].(*big.Int)
+		swap.Amount0Out = values[2].(*big.Int)
+		swap.Amount1Out = values[3].(*big.Int)
+
+		switch {
+		case swap.Amount0In.Sign() > 0 && swap.Amount1Out.Sign() > 0:
+			swap.Direction = ZeroForOne
+		case swap.Amount1In.Sign() > 0 && swap.Amount0Out.Sign() > 0:
+			swap.Direction = OneForZero
+		}
+	}
+
+	return swap, nil
+}
+
+func decodeUniswapV2Sync(log types.Log) (Event, error) {
+	values, meta, err := unpack(uniswapV2SyncEvent, log)
+	if err != nil {
+		return nil, err
+	}
+
+	sync := &UniswapV2Sync{Meta: meta}
+	if values != nil {
+		sync.Reserve0 = values[0].(*big.Int)
+		sync.Reserve1 = values[1].(*big.Int)
+	}
+
+	return sync, nil
+}
+
+func decodeUniswapV3Swap(log types.Log) (Event, error) {
+	values, meta, err := unpack(uniswapV3SwapEvent, log)
+	if err != nil {
+		return nil, err
+	}
+
+	swap := &UniswapV3Swap{
+		Meta:      meta,
+		Sender:    topicAddress(log, 1),
+		Recipient: topicAddress(log, 2),
+	}
+	if values != nil {
+		swap.Amount0 = values[0].(*big.Int)
+		swap.Amount1 = values[1].(*big.Int)
+		swap.SqrtPriceX96 = values[2].(*big.Int)
+		swap.Liquidity = values[3].(*big.Int)
+		swap.Tick = values[4].(*big.Int)
+
+		switch {
+		case swap.Amount0.Sign() > 0:
+			swap.Direction = ZeroForOne
+		case swap.Amount1.Sign() > 0:
+			swap.Direction = OneForZero
+		}
+	}
+
+	return swap, nil
+}
+
+func decodeBalancerSwap(log types.Log) (Event, error) {
+	values, meta, err := unpack(balancerSwapEvent, log)
+	if err != nil {
+		return nil, err
+	}
+
+	swap := &BalancerSwap{
+		Meta:     meta,
+		TokenIn:  topicAddress(log, 2),
+		TokenOut: topicAddress(log, 3),
+	}
+	if len(log.Topics) > 1 {
+		poolID := log.Topics[1]
+		swap.PoolID = &poolID
+	}
+	if values != nil {
+		swap.AmountIn = values[0].(*big.Int)
+		swap.AmountOut = values[1].(*big.Int)
+	}
+
+	return swap, nil
+}
+
+func decodeCurveTokenExchange(log types.Log) (Event, error) {
+	event := curveTokenExchangeEvent
+	if log.Topics[0] == CurveCryptoTokenExchangeTopic {
+		event = curveCryptoExchange
+	}
+
+	values, meta, err := unpack(event, log)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange := &CurveTokenExchange{
+		Meta:  meta,
+		Buyer: topicAddress(log, 1),
+	}
+	if values != nil {
+		exchange.SoldID = values[0].(*big.Int)
+		exchange.TokensSold = values[1].(*big.Int)
+		exchange.BoughtID = values[2].(*big.Int)
+		exchange.TokensBought = values[3].(*big.Int)
+	}
+
+	return exchange, nil
+}
+
+func decodeERC20Transfer(log types.Log) (Event, error) {
+	values, meta, err := unpack(erc20TransferEvent, log)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &ERC20Transfer{
+		Meta: meta,
+		From: topicAddress(log, 1),
+		To:   topicAddress(log, 2),
+	}
+	if values != nil {
+		transfer.Value = values[0].(*big.Int)
+	}
+
+	return transfer, nil
+}
+
+// unpack checks the log shape against the event and unpacks its data.
+// Values are nil when the data was redacted, hints may also omit trailing topics.
+func unpack(event abi.Event, log types.Log) ([]interface{}, Meta, error) {
+	meta := Meta{Address: log.Address}
+
+	indexed := 0
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed++
+		}
+	}
+	// More topics than the event has means it is another event with the same signature,
+	// e.g. ERC-721 `Transfer` which indexes the token id
+	if len(log.Topics) > indexed+1 {
+		return nil, meta, ErrUnknownEvent
+	}
+
+	if len(log.Data) == 0 {
+		meta.Redacted = true
+		return nil, meta, nil
+	}
+
+	values, err := event.Inputs.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return nil, meta, fmt.Errorf("%s: %w", event.Name, err)
+	}
+
+	return values, meta, nil
+}
+
+// topicAddress returns the address in the topic, nil if the hint did not reveal it
+func topicAddress(log types.Log, i int) *common.Address {
+	if i >= len(log.Topics) {
+		return nil
+	}
+
+	address := common.BytesToAddress(log.Topics[i].Bytes())
+	return &address
+}
@@ -0,0 +1,339 @@
+package sse
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// DefaultDedupeWindow is how long a hash is remembered by default
+	DefaultDedupeWindow = 5 * time.Minute
+	// DefaultDedupeSize is the default number of hashes remembered at most
+	DefaultDedupeSize = 100_000
+)
+
+// DedupeOption configures a Deduper or a Dedupe stage
+type DedupeOption func(*dedupeConfig)
+
+type dedupeConfig struct {
+	window        time.Duration
+	size          int
+	reorderWindow time.Duration
+}
+
+// WithDedupeWindow sets how long a hash is remembered
+func WithDedupeWindow(window time.Duration) DedupeOption {
+	return func(c *dedupeConfig) {
+		c.window = window
+	}
+}
+
+// WithDedupeSize sets the number of hashes remembered at most, the oldest are forgotten first
+func WithDedupeSize(size int) DedupeOption {
+	return func(c *dedupeConfig) {
+		c.size = size
+	}
+}
+
+// WithReorderWindow holds events for the window and delivers them in receive order,
+// so events merged from several sources come out in the order they were received.
+// Ignored by a Deduper.
+func WithReorderWindow(window time.Duration) DedupeOption {
+	return func(c *dedupeConfig) {
+		c.reorderWindow = window
+	}
+}
+
+func newDedupeConfig(opts []DedupeOption) *dedupeConfig {
+	c := &dedupeConfig{
+		window: DefaultDedupeWindow,
+		size:   DefaultDedupeSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// DedupeStats are the counters of a Deduper
+type DedupeStats struct {
+	Passed     uint64 // Events seen for the first time
+	Duplicates uint64 // Events dropped as duplicates
+	Tracked    int    // Hashes currently remembered
+}
+
+// Deduper remembers the hashes seen within a time window
+type Deduper struct {
+	window time.Duration
+	size   int
+
+	mu    sync.Mutex
+	seen  map[common.Hash]time.Time
+	order []seenHash // Oldest first
+	stats DedupeStats
+}
+
+type seenHash struct {
+	hash common.Hash
+	at   time.Time
+}
+
+// NewDeduper creates a Deduper
+func NewDeduper(opts ...DedupeOption) *Deduper {
+	c := newDedupeConfig(opts)
+
+	return &Deduper{
+		window: c.window,
+		size:   c.size,
+		seen:   make(map[common.Hash]time.Time),
+	}
+}
+
+// Check records the hash seen at the time, it returns false if it was already seen within the window
+func (d *Deduper) Check(hash common.Hash, at time.Time) bool {
+	_, ok := d.check(hash, at)
+	return ok
+}
+
+// check is Check also returning when the hash was first seen
+func (d *Deduper) check(hash common.Hash, at time.Time) (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expire(at)
+
+	if first, ok := d.seen[hash]; ok {
+		d.stats.Duplicates++
+		return first, false
+	}
+
+	d.seen[hash] = at
+	d.order = append(d.order, seenHash{hash: hash, at: at})
+	d.stats.Passed++
+
+	for d.size > 0 && len(d.seen) > d.size {
+		d.forgetOldest()
+	}
+	return at, true
+}
+
+// Stats returns the counters
+func (d *Deduper) Stats() DedupeStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.stats
+	stats.Tracked = len(d.seen)
+	return stats
+}
+
+// expire forgets the hashes seen before the window, d.mu must be held
+func (d *Deduper) expire(now time.Time) {
+	for len(d.order) > 0 && now.Sub(d.order[0].at) > d.window {
+		d.forgetOldest()
+	}
+}
+
+// forgetOldest forgets the oldest hash, d.mu must be held
+func (d *Deduper) forgetOldest() {
+	oldest := d.order[0]
+	d.order[0] = seenHash{}
+	d.order = d.order[1:]
+
+	if at, ok := d.seen[oldest.hash]; ok && at.Equal(oldest.at) {
+		delete(d.seen, oldest.hash)
+	}
+}
+
+// Dedupe is an SSEClient stage dropping events already delivered within the window.
+// Errors are passed through as they arrive, history requests go straight to the client.
+type Dedupe struct {
+	SSEClient
+
+	opts          []DedupeOption
+	reorderWindow time.Duration
+
+	mu       sync.Mutex
+	dedupers map[*Deduper]struct{} // One per running subscription
+	ended    DedupeStats           // Counters of the subscriptions that ended
+}
+
+// NewDedupe wraps the client with a dedupe stage, every subscription is deduplicated on its own
+func NewDedupe(client SSEClient, opts ...DedupeOption) *Dedupe {
+	c := newDedupeConfig(opts)
+
+	return &Dedupe{
+		SSEClient:     client,
+		opts:          opts,
+		reorderWindow: c.reorderWindow,
+		dedupers:      make(map[*Deduper]struct{}),
+	}
+}
+
+// Stats returns the dedupe counters summed over the subscriptions of the stage,
+// Tracked counts the hashes of the running ones
+func (d *Dedupe) Stats() DedupeStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := d.ended
+	for deduper := range d.dedupers {
+		s := deduper.Stats()
+		stats.Passed += s.Passed
+		stats.Duplicates += s.Duplicates
+		stats.Tracked += s.Tracked
+	}
+	return stats
+}
+
+// Subscribe subscribes with the client and delivers each event once.
+// The event channel is closed once the upstream subscription ends or is stopped.
+func (d *Dedupe) Subscribe(eventChan chan<- Event) (SSESubscription, error) {
+	upstream := make(chan Event, DefaultBufferSize)
+	sub, err := d.SSEClient.Subscribe(upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	stage := &stageSubscription{
+		upstream: sub,
+		stopper:  make(chan struct{}),
+	}
+
+	deduper := NewDeduper(d.opts...)
+	d.mu.Lock()
+	d.dedupers[deduper] = struct{}{}
+	d.mu.Unlock()
+
+	go d.run(stage, deduper, upstream, eventChan)
+
+	return stage, nil
+}
+
+func (d *Dedupe) run(stage *stageSubscription, deduper *Deduper, upstream <-chan Event, eventChan chan<- Event) {
+	defer close(eventChan)
+	defer d.end(deduper)
+
+	send := func(event Event) bool {
+		if event.Data != nil && !deduper.Check(event.Data.Hash, event.ReceivedAt) {
+			return true
+		}
+
+		select {
+		case <-stage.stopper:
+			return false
+		case eventChan <- event:
+			return true
+		}
+	}
+
+	var (
+		pending reorderQueue
+		timer   *time.Timer
+		timeout <-chan time.Time
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-stage.stopper:
+			return
+		case event, ok := <-upstream:
+			if !ok {
+				// Flush what is held back, in order
+				for pending.Len() > 0 {
+					if !send(heap.Pop(&pending).(Event)) {
+						return
+					}
+				}
+				return
+			}
+
+			if event.ReceivedAt.IsZero() {
+				event.ReceivedAt = time.Now()
+			}
+			if d.reorderWindow <= 0 || event.Data == nil {
+				if !send(event) {
+					return
+				}
+				continue
+			}
+			heap.Push(&pending, event)
+		case <-timeout:
+		}
+
+		if pending.Len() == 0 {
+			continue
+		}
+
+		// Deliver everything held back for the full window
+		now := time.Now()
+		for pending.Len() > 0 && now.Sub(pending[0].ReceivedAt) >= d.reorderWindow {
+			if !send(heap.Pop(&pending).(Event)) {
+				return
+			}
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		timeout = nil
+		if pending.Len() > 0 {
+			timer = time.NewTimer(d.reorderWindow - now.Sub(pending[0].ReceivedAt))
+			timeout = timer.C
+		}
+	}
+}
+
+// stageSubscription stops a stage and its upstream subscription
+type stageSubscription struct {
+	upstream SSESubscription
+	stopper  chan struct{}
+	stopOnce sync.Once
+}
+
+// Stop stops the subscription
+func (s *stageSubscription) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopper)
+		s.upstream.Stop()
+	})
+}
+
+// reorderQueue is a min-heap of events by receive time
+type reorderQueue []Event
+
+func (q reorderQueue) Len() int { return len(q) }
+
+func (q reorderQueue) Less(i, j int) bool { return q[i].ReceivedAt.Before(q[j].ReceivedAt) }
+
+func (q reorderQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *reorderQueue) Push(x interface{}) { *q = append(*q, x.(Event)) }
+
+func (q *reorderQueue) Pop() interface{} {
+	old := *q
+	event := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return event
+}
+
+// end adds the counters of a subscription's deduper to the ended ones
+func (d *Dedupe) end(deduper *Deduper) {
+	stats := deduper.Stats()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.dedupers, deduper)
+	d.ended.Passed += stats.Passed
+	d.ended.Duplicates += stats.Duplicates
+}
@@ -0,0 +1,110 @@
+package sse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeduper_Window(t *testing.T) {
+	d := NewDeduper(WithDedupeWindow(time.Minute), WithDedupeSize(2))
+	start := time.Now()
+	a, b, c := common.HexToHash("0xa"), common.HexToHash("0xb"), common.HexToHash("0xc")
+
+	assert.True(t, d.Check(a, start))
+	assert.False(t, d.Check(a, start.Add(time.Second)))
+	assert.True(t, d.Check(b, start.Add(time.Second)))
+
+	// Over the size limit, a is forgotten first
+	assert.True(t, d.Check(c, start.Add(2*time.Second)))
+	assert.True(t, d.Check(a, start.Add(3*time.Second)))
+
+	// Outside the window
+	assert.True(t, d.Check(c, start.Add(2*time.Minute)))
+
+	assert.Equal(t, DedupeStats{Passed: 5, Duplicates: 1, Tracked: 1}, d.Stats())
+}
+
+func TestDedupe_Subscribe(t *testing.T) {
+	client := &fakeClient{}
+	dedupe := NewDedupe(client)
+
+	events := make(chan Event)
+	sub, err := dedupe.Subscribe(events)
+	require.NoError(t, err)
+
+	go func() {
+		client.push(1, 1, 2)
+		client.send(Event{Error: assert.AnError})
+		client.push(2, 3)
+	}()
+
+	assert.Equal(t, common.BytesToHash([]byte{1}), receive(t, events).Data.Hash)
+	assert.Equal(t, common.BytesToHash([]byte{2}), receive(t, events).Data.Hash)
+	assert.ErrorIs(t, receive(t, events).Error, assert.AnError)
+	event := receive(t, events)
+	assert.Equal(t, common.BytesToHash([]byte{3}), event.Data.Hash)
+	assert.False(t, event.ReceivedAt.IsZero())
+
+	assert.Equal(t, uint64(2), dedupe.Stats().Duplicates)
+
+	sub.Stop()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestDedupe_SubscriptionsDedupeOnTheirOwn(t *testing.T) {
+	client := &fakeClient{}
+	dedupe := NewDedupe(client)
+
+	for i := 0; i < 2; i++ {
+		events := make(chan Event)
+		sub, err := dedupe.Subscribe(events)
+		require.NoError(t, err)
+
+		// Every subscription gets the event, even though an earlier one delivered it
+		go client.push(1, 1)
+		assert.Equal(t, common.BytesToHash([]byte{1}), receive(t, events).Data.Hash)
+		require.Eventually(t, func() bool { return dedupe.Stats().Duplicates == uint64(i+1) }, time.Second, time.Millisecond)
+
+		sub.Stop()
+		_, ok := <-events
+		assert.False(t, ok)
+	}
+
+	assert.Equal(t, DedupeStats{Passed: 2, Duplicates: 2}, dedupe.Stats())
+}
+
+func TestDedupe_Reorder(t *testing.T) {
+	client := &fakeClient{}
+	dedupe := NewDedupe(client, WithReorderWindow(50*time.Millisecond))
+
+	events := make(chan Event, 8)
+	_, err := dedupe.Subscribe(events)
+	require.NoError(t, err)
+
+	now := time.Now()
+	at := func(hash byte, offset time.Duration) Event {
+		return Event{Data: &MatchMakerEvent{Hash: common.BytesToHash([]byte{hash})}, ReceivedAt: now.Add(offset)}
+	}
+
+	// Copies from a slower source arrive after the fast source's later event
+	client.send(at(2, 2*time.Millisecond))
+	client.send(at(1, time.Millisecond))
+	client.send(at(2, 3*time.Millisecond))
+	client.send(at(3, 4*time.Millisecond))
+
+	for _, want := range []byte{1, 2, 3} {
+		event := receive(t, events)
+		assert.Equal(t, common.BytesToHash([]byte{want}), event.Data.Hash)
+	}
+
+	client.send(at(4, 5*time.Millisecond))
+	client.Stop()
+	assert.Equal(t, common.BytesToHash([]byte{4}), receive(t, events).Data.Hash)
+	_, ok := <-events
+	assert.False(t, ok)
+}
@@ -1,19 +1,42 @@
 package sse
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
 // For querying historical mev-share transactions
 type EventHistoryParams struct {
 	BlockStart     uint64 `json:"blockStart,omitempty"`
-	BlockEnd       uint64 `json:"blockEnd:,omitempty"`
-	TimestampStart uint64 `json:"timestampStart:,omitempty"`
-	TimestampEnd   uint64 `json:"timestampEnd:,omitempty"`
-	OffSet         uint64 `json:"offset:,omitempty"`
+	BlockEnd       uint64 `json:"blockEnd,omitempty"`
+	TimestampStart uint64 `json:"timestampStart,omitempty"`
+	TimestampEnd   uint64 `json:"timestampEnd,omitempty"`
+	OffSet         uint64 `json:"offset,omitempty"`
+	Limit          uint64 `json:"limit,omitempty"` // At most EventHistoryInfo.MaxLimit, the node's maximum if zero
+}
+
+// query encodes the params as the query parameters of the node's history endpoint, zero values are left out
+func (p EventHistoryParams) query() url.Values {
+	query := make(url.Values)
+	for _, param := range []struct {
+		name  string
+		value uint64
+	}{
+		{"blockStart", p.BlockStart},
+		{"blockEnd", p.BlockEnd},
+		{"timestampStart", p.TimestampStart},
+		{"timestampEnd", p.TimestampEnd},
+		{"offset", p.OffSet},
+		{"limit", p.Limit},
+	} {
+		if param.value != 0 {
+			query.Set(param.name, strconv.FormatUint(param.value, 10))
+		}
+	}
+	return query
 }
 
 // Single historical mev-share transaction
@@ -44,7 +67,7 @@ func (c *InternalClient) EventHistoryInfo() (*EventHistoryInfo, error) {
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -67,33 +90,50 @@ func (c *InternalClient) EventHistoryInfo() (*EventHistoryInfo, error) {
 // Gets historical mev-share data
 func (c *InternalClient) GetEventHistory(params EventHistoryParams) ([]EventHistory, error) {
 	url := c.BaseURL + "/api/v1/history"
-
-	jsonParams, err := json.Marshal(params)
-	if err != nil {
-		return nil, err
+	if query := params.query().Encode(); query != "" {
+		url += "?" + query
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonParams))
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.client().Do(req)
 	if err != nil {
+		c.log().Warn("event history request failed", "url", url, "err", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		c.log().Warn("event history request failed", "url", url, "status", resp.StatusCode)
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	var eventHistory []EventHistory
-	err = json.NewDecoder(resp.Body).Decode(&eventHistory)
+	var rawHistory []struct {
+		Block     uint64          `json:"block"`
+		Timestamp uint64          `json:"timestamp"`
+		Hint      json.RawMessage `json:"hint"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&rawHistory)
 	if err != nil {
 		return nil, err
 	}
 
+	eventHistory := make([]EventHistory, 0, len(rawHistory))
+	for i, h := range rawHistory {
+		history := EventHistory{Block: h.Block, Timestamp: h.Timestamp}
+		if len(h.Hint) > 0 {
+			hint, err := DecodeEvent(h.Hint, c.mode)
+			if err != nil {
+				return nil, fmt.Errorf("event %d: %w", i, err)
+			}
+			history.Hint = *hint
+		}
+		eventHistory = append(eventHistory, history)
+	}
+	c.log().Debug("event history", "url", url, "events", len(eventHistory))
+
 	return eventHistory, nil
 }
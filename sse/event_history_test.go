@@ -45,17 +45,15 @@ func TestInternalClient_GetEventHistory(t *testing.T) {
 		BlockEnd:       20000,
 		TimestampStart: 1631419200,
 		TimestampEnd:   1631422800,
-		OffSet:         0,
+		OffSet:         2,
+		Limit:          50,
 	}
 
 	// Create a mock HTTP server
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "/api/v1/history", r.URL.Path)
-
-		var receivedParams EventHistoryParams
-		err := json.NewDecoder(r.Body).Decode(&receivedParams)
-		assert.NoError(t, err)
-		assert.Equal(t, params, receivedParams)
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "blockEnd=20000&blockStart=10000&limit=50&offset=2&timestampEnd=1631422800&timestampStart=1631419200", r.URL.RawQuery)
 
 		history := []EventHistory{
 			{
@@ -73,7 +71,7 @@ func TestInternalClient_GetEventHistory(t *testing.T) {
 				},
 			},
 		}
-		err = json.NewEncoder(w).Encode(history)
+		err := json.NewEncoder(w).Encode(history)
 		if err != nil {
 			panic(err)
 		}
@@ -94,3 +92,10 @@ func TestInternalClient_GetEventHistory(t *testing.T) {
 	assert.Equal(t, uint64(1631419320), history[1].Timestamp)
 	assert.Equal(t, common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"), history[1].Hint.Hash)
 }
+
+func TestEventHistoryParams_JSON(t *testing.T) {
+	data, err := json.Marshal(EventHistoryParams{BlockStart: 1, BlockEnd: 2, TimestampStart: 3, TimestampEnd: 4, OffSet: 5, Limit: 6})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"blockStart":1,"blockEnd":2,"timestampStart":3,"timestampEnd":4,"offset":5,"limit":6}`, string(data))
+	assert.Empty(t, EventHistoryParams{}.query())
+}
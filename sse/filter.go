@@ -0,0 +1,164 @@
+package sse
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Filter reports whether an event should be delivered
+type Filter func(event *MatchMakerEvent) bool
+
+// Match reports whether the event passes the filter, a nil filter matches everything
+func (f Filter) Match(event *MatchMakerEvent) bool {
+	if f == nil {
+		return true
+	}
+
+	return f(event)
+}
+
+// And matches events matching all the filters
+func And(filters ...Filter) Filter {
+	return func(event *MatchMakerEvent) bool {
+		for _, f := range filters {
+			if !f.Match(event) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches events matching any of the filters
+func Or(filters ...Filter) Filter {
+	return func(event *MatchMakerEvent) bool {
+		for _, f := range filters {
+			if f.Match(event) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches events not matching the filter
+func Not(filter Filter) Filter {
+	return func(event *MatchMakerEvent) bool {
+		return !filter.Match(event)
+	}
+}
+
+// To matches events with a transaction sent to one of the addresses
+func To(addresses ...common.Address) Filter {
+	set := addressSet(addresses)
+
+	return func(event *MatchMakerEvent) bool {
+		for _, tx := range event.Txs {
+			if _, ok := set[tx.To]; ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FunctionSelector matches events with a transaction calling one of the selectors
+func FunctionSelector(selectors ...[4]byte) Filter {
+	set := make(map[[4]byte]struct{}, len(selectors))
+	for _, s := range selectors {
+		set[s] = struct{}{}
+	}
+
+	return func(event *MatchMakerEvent) bool {
+		for _, tx := range event.Txs {
+			if _, ok := set[tx.FunctionSelector]; ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// LogAddress matches events with a log emitted by one of the addresses
+func LogAddress(addresses ...common.Address) Filter {
+	set := addressSet(addresses)
+
+	return func(event *MatchMakerEvent) bool {
+		for _, log := range event.Logs {
+			if _, ok := set[log.Address]; ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// LogTopic matches events with a log whose first topic (the event signature) is one of the topics
+func LogTopic(topics ...common.Hash) Filter {
+	set := make(map[common.Hash]struct{}, len(topics))
+	for _, t := range topics {
+		set[t] = struct{}{}
+	}
+
+	return func(event *MatchMakerEvent) bool {
+		for _, log := range event.Logs {
+			if len(log.Topics) == 0 {
+				continue
+			}
+			if _, ok := set[log.Topics[0]]; ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MinGasUsed matches events revealing a gas usage of at least min, either for the
+// whole event or for one of its transactions
+func MinGasUsed(min *big.Int) Filter {
+	return func(event *MatchMakerEvent) bool {
+		if event.GasUsed != nil && event.GasUsed.ToInt().Cmp(min) >= 0 {
+			return true
+		}
+		for _, tx := range event.Txs {
+			if tx.GasUsed != nil && tx.GasUsed.ToInt().Cmp(min) >= 0 {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasCallData matches events revealing the calldata of a transaction
+func HasCallData() Filter {
+	return func(event *MatchMakerEvent) bool {
+		for _, tx := range event.Txs {
+			if len(tx.CallData) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// IsBundle matches bundle hints, i.e. events with more than one transaction
+func IsBundle() Filter {
+	return func(event *MatchMakerEvent) bool {
+		return len(event.Txs) > 1
+	}
+}
+
+// IsTransaction matches single transaction hints
+func IsTransaction() Filter {
+	return Not(IsBundle())
+}
+
+func addressSet(addresses []common.Address) map[common.Address]struct{} {
+	set := make(map[common.Address]struct{}, len(addresses))
+	for _, a := range addresses {
+		set[a] = struct{}{}
+	}
+
+	return set
+}
@@ -0,0 +1,115 @@
+package sse
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	routerAddress = common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+	poolAddress   = common.HexToAddress("0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc")
+	swapSelector  = [4]byte{0x38, 0xed, 0x17, 0x39}
+	swapTopic     = common.HexToHash("0xd78ad95fa46c994b6551d0da85fc275fe613ce37657fb8d5e3d130840159d822")
+)
+
+func filterEvent() *MatchMakerEvent {
+	return &MatchMakerEvent{
+		Logs: []types.Log{{Address: poolAddress, Topics: []common.Hash{swapTopic}}},
+		Txs: []PendingTransaction{{
+			To:               routerAddress,
+			FunctionSelector: swapSelector,
+			GasUsed:          (*hexutil.Big)(big.NewInt(150000)),
+		}},
+	}
+}
+
+func TestFilters(t *testing.T) {
+	event := filterEvent()
+	other := common.HexToAddress("0x01")
+
+	assert.True(t, Filter(nil).Match(event))
+
+	assert.True(t, To(other, routerAddress).Match(event))
+	assert.False(t, To(other).Match(event))
+
+	assert.True(t, FunctionSelector(swapSelector).Match(event))
+	assert.False(t, FunctionSelector([4]byte{1, 2, 3, 4}).Match(event))
+
+	assert.True(t, LogAddress(poolAddress).Match(event))
+	assert.False(t, LogAddress(routerAddress).Match(event))
+
+	assert.True(t, LogTopic(swapTopic).Match(event))
+	assert.False(t, LogTopic(common.Hash{}).Match(event))
+
+	assert.True(t, MinGasUsed(big.NewInt(150000)).Match(event))
+	assert.False(t, MinGasUsed(big.NewInt(150001)).Match(event))
+
+	assert.False(t, HasCallData().Match(event))
+	event.Txs[0].CallData = []byte{0x01}
+	assert.True(t, HasCallData().Match(event))
+
+	assert.True(t, IsTransaction().Match(event))
+	assert.False(t, IsBundle().Match(event))
+	event.Txs = append(event.Txs, PendingTransaction{})
+	assert.True(t, IsBundle().Match(event))
+}
+
+func TestFilters_Combinators(t *testing.T) {
+	event := filterEvent()
+	other := common.HexToAddress("0x01")
+
+	assert.True(t, And(To(routerAddress), LogTopic(swapTopic)).Match(event))
+	assert.False(t, And(To(routerAddress), To(other)).Match(event))
+	assert.True(t, Or(To(other), LogAddress(poolAddress)).Match(event))
+	assert.False(t, Or(To(other), LogAddress(other)).Match(event))
+	assert.True(t, Not(To(other)).Match(event))
+	assert.True(t, And().Match(event))
+	assert.False(t, Or().Match(event))
+}
+
+func TestInternalClient_Subscribe_WithFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i, to := range []common.Address{common.HexToAddress("0x01"), routerAddress} {
+			hash := common.BytesToHash([]byte{byte(i + 1)})
+			_, _ = fmt.Fprintf(w, "data: {\"hash\":\"%s\",\"txs\":[{\"to\":\"%s\"}]}\n\n", hash, to)
+		}
+	}))
+	defer server.Close()
+
+	eventChan := make(chan Event, 2)
+	_, err := New(server.URL, WithFilter(To(routerAddress))).Subscribe(eventChan)
+	require.NoError(t, err)
+
+	select {
+	case event := <-eventChan:
+		require.NoError(t, event.Error)
+		assert.Equal(t, common.BytesToHash([]byte{2}), event.Data.Hash)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for event")
+	}
+}
+
+func TestBroker_SubscriberFilter(t *testing.T) {
+	client := &fakeClient{}
+	broker := NewBroker(client)
+	require.NoError(t, broker.Start())
+	defer broker.Stop()
+
+	odd := broker.Subscribe(WithSubscriberFilter(func(event *MatchMakerEvent) bool {
+		return event.Hash[31]%2 == 1
+	}))
+
+	client.push(1, 2, 3)
+	assert.Equal(t, common.BytesToHash([]byte{1}), receive(t, odd.Events()).Data.Hash)
+	assert.Equal(t, common.BytesToHash([]byte{3}), receive(t, odd.Events()).Data.Hash)
+}
@@ -0,0 +1,177 @@
+package sse
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyWindow is the default number of samples kept per latency histogram
+const DefaultLatencyWindow = 1024
+
+// Head is a block header
+type Head struct {
+	Number    uint64
+	Timestamp time.Time
+}
+
+// HeadSource reports the latest block, e.g. from a node's newHeads subscription
+type HeadSource interface {
+	// Head returns the latest block, ok is false while it is unknown
+	Head() (head Head, ok bool)
+}
+
+// HeadFunc adapts a function to a HeadSource
+type HeadFunc func() (Head, bool)
+
+// Head calls f
+func (f HeadFunc) Head() (Head, bool) {
+	return f()
+}
+
+// LatencySummary summarizes the samples of a latency histogram
+type LatencySummary struct {
+	Count int
+	P50   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// LatencyStats are the receive latencies measured against the node's timestamps
+// and against the latest block timestamp
+type LatencyStats struct {
+	Node  LatencySummary
+	Block LatencySummary
+}
+
+// LatencyOption configures a LatencyTracker
+type LatencyOption func(*LatencyTracker)
+
+// WithLatencyWindow sets the number of samples kept per histogram
+func WithLatencyWindow(samples int) LatencyOption {
+	return func(t *LatencyTracker) {
+		if samples > 0 {
+			t.window = samples
+		}
+	}
+}
+
+// LatencyTracker keeps rolling histograms of how late events are received.
+// Events with a node timestamp are measured against it, others against the latest block of the head source.
+// The live stream carries no node timestamps, NodeTime is only set on events backfilled with
+// SubscribeWithBackfill and replayed by sse/replay, so live latency is measured against the head source.
+type LatencyTracker struct {
+	head   HeadSource
+	window int
+
+	mu    sync.Mutex
+	node  *rollingHistogram
+	block *rollingHistogram
+}
+
+// NewLatencyTracker creates a tracker, head may be nil to only use node timestamps
+func NewLatencyTracker(head HeadSource, opts ...LatencyOption) *LatencyTracker {
+	t := &LatencyTracker{
+		head:   head,
+		window: DefaultLatencyWindow,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	t.node = newRollingHistogram(t.window)
+	t.block = newRollingHistogram(t.window)
+	return t
+}
+
+// Observe records the latency of the event, ok is false when there is nothing to measure it against
+func (t *LatencyTracker) Observe(event Event) (latency time.Duration, ok bool) {
+	if event.Data == nil || event.ReceivedAt.IsZero() {
+		return 0, false
+	}
+
+	if !event.NodeTime.IsZero() {
+		latency = event.ReceivedAt.Sub(event.NodeTime)
+
+		t.mu.Lock()
+		t.node.add(latency)
+		t.mu.Unlock()
+		return latency, true
+	}
+
+	if t.head == nil {
+		return 0, false
+	}
+	head, ok := t.head.Head()
+	if !ok {
+		return 0, false
+	}
+	latency = event.ReceivedAt.Sub(head.Timestamp)
+
+	t.mu.Lock()
+	t.block.add(latency)
+	t.mu.Unlock()
+	return latency, true
+}
+
+// Stats summarizes the samples in the window
+func (t *LatencyTracker) Stats() LatencyStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return LatencyStats{
+		Node:  t.node.summary(),
+		Block: t.block.summary(),
+	}
+}
+
+// rollingHistogram keeps the latest samples
+type rollingHistogram struct {
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func newRollingHistogram(size int) *rollingHistogram {
+	return &rollingHistogram{samples: make([]time.Duration, size)}
+}
+
+func (h *rollingHistogram) add(d time.Duration) {
+	h.samples[h.next] = d
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+func (h *rollingHistogram) summary() LatencySummary {
+	n := h.next
+	if h.full {
+		n = len(h.samples)
+	}
+	if n == 0 {
+		return LatencySummary{}
+	}
+
+	sorted := append([]time.Duration(nil), h.samples[:n]...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return LatencySummary{
+		Count: n,
+		P50:   quantile(sorted, 0.50),
+		P99:   quantile(sorted, 0.99),
+		Max:   sorted[n-1],
+	}
+}
+
+// quantile returns the nearest-rank quantile of sorted samples
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	rank := int(q*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
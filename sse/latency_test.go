@@ -0,0 +1,75 @@
+package sse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	blockTime := time.Unix(1700000000, 0)
+	var head Head
+	known := false
+	tracker := NewLatencyTracker(HeadFunc(func() (Head, bool) { return head, known }), WithLatencyWindow(100))
+
+	received := func(offset time.Duration) Event {
+		return Event{Data: &MatchMakerEvent{}, ReceivedAt: blockTime.Add(offset)}
+	}
+
+	_, ok := tracker.Observe(received(time.Second))
+	assert.False(t, ok)
+
+	head, known = Head{Number: 1, Timestamp: blockTime}, true
+	for i := 1; i <= 200; i++ {
+		latency, ok := tracker.Observe(received(time.Duration(i) * time.Millisecond))
+		require.True(t, ok)
+		assert.Equal(t, time.Duration(i)*time.Millisecond, latency)
+	}
+
+	event := received(3 * time.Second)
+	event.NodeTime = blockTime.Add(2 * time.Second)
+	latency, ok := tracker.Observe(event)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, latency)
+
+	_, ok = tracker.Observe(Event{Error: assert.AnError, ReceivedAt: blockTime})
+	assert.False(t, ok)
+
+	// Only the latest 100 samples are kept
+	stats := tracker.Stats()
+	assert.Equal(t, LatencySummary{Count: 100, P50: 150 * time.Millisecond, P99: 199 * time.Millisecond, Max: 200 * time.Millisecond}, stats.Block)
+	assert.Equal(t, LatencySummary{Count: 1, P50: time.Second, P99: time.Second, Max: time.Second}, stats.Node)
+}
+
+func TestSubscription_Latency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("data: {\"hash\":\"0x0000000000000000000000000000000000000000000000000000000000000001\"}\n\n"))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	blockTime := time.Now().Add(-2 * time.Second)
+	head := HeadFunc(func() (Head, bool) {
+		return Head{Number: 1, Timestamp: blockTime}, true
+	})
+
+	eventChan := make(chan Event, 1)
+	sub, err := New(server.URL, WithLatencyTracking(head)).Subscribe(eventChan)
+	require.NoError(t, err)
+
+	event := receive(t, eventChan)
+	require.NoError(t, event.Error)
+	assert.GreaterOrEqual(t, event.Latency, 2*time.Second)
+	assert.Equal(t, 1, sub.(*Subscription).Latency().Count)
+
+	// Not tracked by default
+	eventChan = make(chan Event, 1)
+	sub, err = New(server.URL).Subscribe(eventChan)
+	require.NoError(t, err)
+	assert.Zero(t, receive(t, eventChan).Latency)
+	assert.Equal(t, LatencySummary{}, sub.(*Subscription).Latency())
+}
@@ -0,0 +1,33 @@
+package sse
+
+import "time"
+
+// Metrics receives the measurements of a client's subscriptions, see the prommetrics package for Prometheus collectors
+type Metrics interface {
+	// EventReceived is called for every event read from a stream, before filtering
+	EventReceived()
+	// DecodeError is called for every event that failed to decode
+	DecodeError()
+	// Reconnected is called when a stream was reopened
+	Reconnected()
+	// PingGap is called with the time since the previous ping of the stream
+	PingGap(gap time.Duration)
+	// Backpressure is called with how long delivering an event to the event channel blocked
+	Backpressure(wait time.Duration)
+}
+
+// WithMetrics reports the measurements of every subscription to m
+func WithMetrics(m Metrics) Option {
+	return func(c *InternalClient) {
+		c.metrics = m
+	}
+}
+
+// nopMetrics is used when no metrics are configured
+type nopMetrics struct{}
+
+func (nopMetrics) EventReceived()             {}
+func (nopMetrics) DecodeError()               {}
+func (nopMetrics) Reconnected()               {}
+func (nopMetrics) PingGap(time.Duration)      {}
+func (nopMetrics) Backpressure(time.Duration) {}
@@ -0,0 +1,363 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultStallTimeout is how long an endpoint may lag behind the others before it is reconnected
+	DefaultStallTimeout = 10 * time.Second
+	// DefaultReconnectDelay is the default wait before reconnecting an endpoint
+	DefaultReconnectDelay = time.Second
+)
+
+// ErrNoEndpoints is returned by a Redundant client without endpoints
+var ErrNoEndpoints = errors.New("no endpoints")
+
+// RedundantOption configures a Redundant client
+type RedundantOption func(*Redundant)
+
+// WithClientOptions sets the options of the client created for every endpoint
+func WithClientOptions(opts ...Option) RedundantOption {
+	return func(r *Redundant) {
+		r.clientOpts = append(r.clientOpts, opts...)
+	}
+}
+
+// WithStallTimeout reconnects an endpoint that delivered nothing for the timeout while the others delivered events,
+// 0 disables stall detection
+func WithStallTimeout(timeout time.Duration) RedundantOption {
+	return func(r *Redundant) {
+		r.stallTimeout = timeout
+	}
+}
+
+// WithReconnectDelay sets the wait before reconnecting an endpoint
+func WithReconnectDelay(delay time.Duration) RedundantOption {
+	return func(r *Redundant) {
+		r.reconnectDelay = delay
+	}
+}
+
+// WithRedundantDedupe configures how long delivered hashes are remembered, see NewDeduper
+func WithRedundantDedupe(opts ...DedupeOption) RedundantOption {
+	return func(r *Redundant) {
+		r.dedupeOpts = append(r.dedupeOpts, opts...)
+	}
+}
+
+// EndpointStats are the statistics of one endpoint of a Redundant client
+type EndpointStats struct {
+	URL        string
+	Connected  bool
+	Events     uint64        // Events received
+	Wins       uint64        // Events this endpoint delivered first
+	WinRate    float64       // Share of all delivered events this endpoint delivered first
+	Lag        time.Duration // Mean delay behind the first copy, over the events it did not win
+	Reconnects uint64
+	LastEvent  time.Time
+	LastError  error // Last connection error
+}
+
+// Redundant subscribes to several endpoints at once and delivers the earliest copy of each event.
+// Endpoints that disconnect or stall are reconnected while the others keep delivering.
+type Redundant struct {
+	clientOpts     []Option
+	dedupeOpts     []DedupeOption
+	stallTimeout   time.Duration
+	reconnectDelay time.Duration
+
+	endpoints []*endpoint
+
+	mu        sync.Mutex
+	delivered uint64
+}
+
+// endpoint is one stream of a Redundant client
+type endpoint struct {
+	url     string
+	client  SSEClient
+	restart chan struct{}
+
+	// Guarded by Redundant.mu
+	stats    EndpointStats
+	lagTotal time.Duration
+	active   time.Time // Last event, or connection time if more recent
+}
+
+// NewRedundant creates a client for the stream endpoints
+func NewRedundant(urls []string, opts ...RedundantOption) *Redundant {
+	r := &Redundant{
+		stallTimeout:   DefaultStallTimeout,
+		reconnectDelay: DefaultReconnectDelay,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for _, url := range urls {
+		r.addEndpoint(url, New(url, r.clientOpts...))
+	}
+	return r
+}
+
+func (r *Redundant) addEndpoint(url string, client SSEClient) {
+	r.endpoints = append(r.endpoints, &endpoint{
+		url:     url,
+		client:  client,
+		restart: make(chan struct{}, 1),
+		stats:   EndpointStats{URL: url},
+	})
+}
+
+// Stats returns the statistics of every endpoint, in the order they were given
+func (r *Redundant) Stats() []EndpointStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]EndpointStats, 0, len(r.endpoints))
+	for _, ep := range r.endpoints {
+		s := ep.stats
+		if r.delivered > 0 {
+			s.WinRate = float64(s.Wins) / float64(r.delivered)
+		}
+		if lost := s.Events - s.Wins; lost > 0 {
+			s.Lag = ep.lagTotal / time.Duration(lost)
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// redundantEvent is an event received from an endpoint
+type redundantEvent struct {
+	endpoint *endpoint
+	event    Event
+}
+
+// Subscribe subscribes to every endpoint and delivers the earliest copy of each event.
+// Decode errors are delivered with the endpoint URL, connection errors only show in Stats.
+// The event channel is closed once the subscription is stopped.
+func (r *Redundant) Subscribe(eventChan chan<- Event) (SSESubscription, error) {
+	if len(r.endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	sub := &redundantSubscription{stopper: make(chan struct{})}
+	merged := make(chan redundantEvent, DefaultBufferSize)
+
+	for _, ep := range r.endpoints {
+		sub.wg.Add(1)
+		go r.runEndpoint(sub, ep, merged)
+	}
+
+	go r.merge(sub, merged, eventChan)
+
+	return sub, nil
+}
+
+// merge delivers the first copy of every event and restarts stalled endpoints
+func (r *Redundant) merge(sub *redundantSubscription, merged <-chan redundantEvent, eventChan chan<- Event) {
+	defer close(eventChan)
+
+	deduper := NewDeduper(r.dedupeOpts...)
+
+	var stallCheck <-chan time.Time
+	if r.stallTimeout > 0 {
+		ticker := time.NewTicker(r.stallTimeout / 2)
+		defer ticker.Stop()
+		stallCheck = ticker.C
+	}
+
+	for {
+		select {
+		case <-sub.stopper:
+			sub.wg.Wait()
+			return
+		case <-stallCheck:
+			r.restartStalled(time.Now())
+		case received := <-merged:
+			event := received.event
+			if event.ReceivedAt.IsZero() {
+				event.ReceivedAt = time.Now()
+			}
+
+			if event.Error != nil {
+				event.Error = fmt.Errorf("%s: %w", received.endpoint.url, event.Error)
+			} else if !r.record(deduper, received.endpoint, event) {
+				continue
+			}
+
+			select {
+			case <-sub.stopper:
+				sub.wg.Wait()
+				return
+			case eventChan <- event:
+			}
+		}
+	}
+}
+
+// record updates the endpoint statistics, it returns whether the event is the first copy
+func (r *Redundant) record(deduper *Deduper, ep *endpoint, event Event) bool {
+	first, isFirst := deduper.check(event.Data.Hash, event.ReceivedAt)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ep.stats.Events++
+	ep.stats.LastEvent = event.ReceivedAt
+	ep.active = event.ReceivedAt
+	if isFirst {
+		ep.stats.Wins++
+		r.delivered++
+	} else if lag := event.ReceivedAt.Sub(first); lag > 0 {
+		ep.lagTotal += lag
+	}
+
+	return isFirst
+}
+
+// restartStalled reconnects endpoints that delivered nothing for the stall timeout while another endpoint did
+func (r *Redundant) restartStalled(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest time.Time
+	for _, ep := range r.endpoints {
+		if ep.active.After(latest) {
+			latest = ep.active
+		}
+	}
+
+	for _, ep := range r.endpoints {
+		if !ep.stats.Connected || now.Sub(ep.active) < r.stallTimeout || latest.Sub(ep.active) <= 0 {
+			continue
+		}
+
+		select {
+		case ep.restart <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// runEndpoint keeps the endpoint subscribed until the subscription is stopped
+func (r *Redundant) runEndpoint(sub *redundantSubscription, ep *endpoint, merged chan<- redundantEvent) {
+	defer sub.wg.Done()
+
+	for first := true; ; first = false {
+		if !first && !sub.sleep(r.reconnectDelay) {
+			return
+		}
+
+		events := make(chan Event, DefaultBufferSize)
+		upstream, err := ep.client.Subscribe(events)
+		if err != nil {
+			r.mu.Lock()
+			ep.stats.LastError = err
+			r.mu.Unlock()
+			continue
+		}
+
+		r.mu.Lock()
+		ep.stats.Connected = true
+		ep.active = time.Now()
+		// A restart requested for the previous connection does not apply to this one
+		select {
+		case <-ep.restart:
+		default:
+		}
+		r.mu.Unlock()
+
+		stopped := r.forward(sub, ep, events, merged)
+		upstream.Stop()
+
+		r.mu.Lock()
+		ep.stats.Connected = false
+		if !stopped {
+			ep.stats.Reconnects++
+		}
+		r.mu.Unlock()
+
+		if stopped {
+			return
+		}
+	}
+}
+
+// forward passes the endpoint events on until the stream ends or is restarted, it returns true once stopped
+func (r *Redundant) forward(sub *redundantSubscription, ep *endpoint, events <-chan Event, merged chan<- redundantEvent) bool {
+	for {
+		select {
+		case <-sub.stopper:
+			return true
+		case <-ep.restart:
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+
+			select {
+			case <-sub.stopper:
+				return true
+			case merged <- redundantEvent{endpoint: ep, event: event}:
+			}
+		}
+	}
+}
+
+// EventHistoryInfo asks the endpoints in order until one answers
+func (r *Redundant) EventHistoryInfo() (*EventHistoryInfo, error) {
+	err := ErrNoEndpoints
+	for _, ep := range r.endpoints {
+		var info *EventHistoryInfo
+		if info, err = ep.client.EventHistoryInfo(); err == nil {
+			return info, nil
+		}
+	}
+	return nil, err
+}
+
+// GetEventHistory asks the endpoints in order until one answers
+func (r *Redundant) GetEventHistory(params EventHistoryParams) ([]EventHistory, error) {
+	err := ErrNoEndpoints
+	for _, ep := range r.endpoints {
+		var history []EventHistory
+		if history, err = ep.client.GetEventHistory(params); err == nil {
+			return history, nil
+		}
+	}
+	return nil, err
+}
+
+// redundantSubscription stops the subscriptions to all endpoints
+type redundantSubscription struct {
+	stopper  chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// Stop stops the subscription
+func (s *redundantSubscription) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopper)
+	})
+}
+
+// sleep waits for the duration, it returns false if the subscription was stopped meanwhile
+func (s *redundantSubscription) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-s.stopper:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
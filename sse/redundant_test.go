@@ -0,0 +1,104 @@
+package sse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedundant(opts ...RedundantOption) (*Redundant, *fakeClient, *fakeClient) {
+	a, b := &fakeClient{}, &fakeClient{}
+
+	r := NewRedundant(nil, opts...)
+	r.addEndpoint("a", a)
+	r.addEndpoint("b", b)
+	return r, a, b
+}
+
+func TestRedundant_EarliestCopy(t *testing.T) {
+	r, a, b := newTestRedundant(WithStallTimeout(0))
+
+	events := make(chan Event)
+	sub, err := r.Subscribe(events)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return a.subscribed() && b.subscribed() }, time.Second, time.Millisecond)
+
+	now := time.Now()
+	event := func(hash byte, at time.Time) Event {
+		return Event{Data: &MatchMakerEvent{Hash: common.BytesToHash([]byte{hash})}, ReceivedAt: at}
+	}
+
+	// Endpoints forward concurrently, events are sent one at a time to keep the order
+	expect := func(c *fakeClient, e Event, want byte) {
+		go c.send(e)
+		assert.Equal(t, common.BytesToHash([]byte{want}), receive(t, events).Data.Hash)
+	}
+
+	expect(a, event(1, now), 1)
+	expect(b, event(2, now.Add(time.Millisecond)), 2)
+
+	go b.send(Event{Error: assert.AnError})
+	err = receive(t, events).Error
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.ErrorContains(t, err, "b: ")
+
+	b.send(event(1, now.Add(10*time.Millisecond)))
+	a.send(event(2, now.Add(4*time.Millisecond)))
+	expect(a, event(3, now.Add(20*time.Millisecond)), 3)
+
+	// Duplicates are only counted, wait for the other endpoint's copy
+	assert.Eventually(t, func() bool { return r.Stats()[1].Events == 2 }, time.Second, time.Millisecond)
+	stats := r.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, EndpointStats{URL: "a", Connected: true, Events: 3, Wins: 2, WinRate: 2.0 / 3, Lag: 3 * time.Millisecond, LastEvent: now.Add(20 * time.Millisecond)}, stats[0])
+	assert.Equal(t, uint64(1), stats[1].Wins)
+	assert.Equal(t, 10*time.Millisecond, stats[1].Lag)
+
+	sub.Stop()
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.False(t, r.Stats()[0].Connected)
+}
+
+func TestRedundant_Failover(t *testing.T) {
+	r, a, b := newTestRedundant(WithStallTimeout(40*time.Millisecond), WithReconnectDelay(time.Millisecond))
+
+	events := make(chan Event, 64)
+	sub, err := r.Subscribe(events)
+	require.NoError(t, err)
+	defer sub.Stop()
+	assert.Eventually(t, func() bool { return a.subscribed() && b.subscribed() }, time.Second, time.Millisecond)
+
+	// b stalls while a keeps delivering
+	done := make(chan struct{})
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(5 * time.Millisecond):
+				a.push(byte(i))
+			}
+		}
+	}()
+	assert.Eventually(t, func() bool { return r.Stats()[1].Reconnects > 0 }, time.Second, 5*time.Millisecond)
+	close(done)
+
+	// a ends its stream and is reconnected
+	reconnects := r.Stats()[0].Reconnects
+	a.Stop()
+	assert.Eventually(t, func() bool { return r.Stats()[0].Reconnects > reconnects }, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return r.Stats()[0].Connected }, time.Second, 5*time.Millisecond)
+}
+
+func TestRedundant_NoEndpoints(t *testing.T) {
+	r := NewRedundant(nil)
+
+	_, err := r.Subscribe(make(chan Event))
+	assert.ErrorIs(t, err, ErrNoEndpoints)
+	_, err = r.EventHistoryInfo()
+	assert.ErrorIs(t, err, ErrNoEndpoints)
+}
@@ -0,0 +1,199 @@
+// Package replay is an sse.SSEClient serving recorded events, for offline replay and backtesting
+package replay
+
+import (
+	"sync"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+)
+
+// DefaultMaxLimit is the default number of events returned per history request
+const DefaultMaxLimit = 500
+
+// Client replays entries as an sse.SSEClient.
+// Subscriptions emit the entries in order, history requests are answered from the same entries.
+type Client struct {
+	entries  []Entry
+	speed    float64
+	maxLimit uint64
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithSpeed replays at a multiple of the recorded timing, 2 replays twice as fast.
+// 0 replays as fast as possible, the default is 1.
+func WithSpeed(multiplier float64) Option {
+	return func(c *Client) {
+		c.speed = multiplier
+	}
+}
+
+// WithMaxLimit sets the number of events returned per history request, 0 means DefaultMaxLimit
+func WithMaxLimit(limit uint64) Option {
+	return func(c *Client) {
+		c.maxLimit = limit
+	}
+}
+
+// New creates a Client replaying the entries
+func New(entries []Entry, opts ...Option) *Client {
+	c := &Client{
+		entries:  entries,
+		speed:    1,
+		maxLimit: DefaultMaxLimit,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxLimit == 0 {
+		c.maxLimit = DefaultMaxLimit
+	}
+
+	return c
+}
+
+// Open creates a Client replaying the recording at path, see Load
+func Open(path string, opts ...Option) (*Client, error) {
+	entries, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(entries, opts...), nil
+}
+
+// Entries returns the replayed entries
+func (c *Client) Entries() []Entry {
+	return c.entries
+}
+
+// Subscribe replays the entries, the channel is closed after the last one.
+// Entries without a time are sent right after the previous one.
+// Events carry the recorded receive time as ReceivedAt, or the time they were sent if none was recorded,
+// and the recorded node time as NodeTime.
+func (c *Client) Subscribe(eventChan chan<- sse.Event) (sse.SSESubscription, error) {
+	sub := &subscription{stopper: make(chan struct{})}
+
+	go func() {
+		defer close(eventChan)
+
+		var last time.Time
+		for i := range c.entries {
+			entry := c.entries[i]
+
+			if c.speed > 0 && !last.IsZero() && entry.Time.After(last) {
+				timer := time.NewTimer(time.Duration(float64(entry.Time.Sub(last)) / c.speed))
+				select {
+				case <-sub.stopper:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+			if !entry.Time.IsZero() {
+				last = entry.Time
+			}
+
+			event := sse.Event{Data: &entry.Event, ReceivedAt: entry.ReceivedAt, NodeTime: entry.NodeTime}
+			if event.ReceivedAt.IsZero() {
+				event.ReceivedAt = time.Now()
+			}
+
+			select {
+			case <-sub.stopper:
+				return
+			case eventChan <- event:
+			}
+		}
+	}()
+
+	return sub, nil
+}
+
+// EventHistoryInfo describes the entries
+func (c *Client) EventHistoryInfo() (*sse.EventHistoryInfo, error) {
+	info := &sse.EventHistoryInfo{
+		Count:    uint64(len(c.entries)),
+		MaxLimit: c.maxLimit,
+	}
+	for i, entry := range c.entries {
+		if i == 0 || entry.Block < info.MinBlock {
+			info.MinBlock = entry.Block
+		}
+		if entry.Block > info.MaxBlock {
+			info.MaxBlock = entry.Block
+		}
+		if ts := timestamp(entry); i == 0 || ts < info.MinTimestamp {
+			info.MinTimestamp = ts
+		}
+	}
+
+	return info, nil
+}
+
+// GetEventHistory returns one page of at most params.Limit, capped at MaxLimit, matching entries.
+// Entries without a block or time only match params without block or timestamp bounds.
+func (c *Client) GetEventHistory(params sse.EventHistoryParams) ([]sse.EventHistory, error) {
+	history := make([]sse.EventHistory, 0)
+	skip := params.OffSet
+	limit := c.maxLimit
+	if params.Limit != 0 && params.Limit < limit {
+		limit = params.Limit
+	}
+
+	for _, entry := range c.entries {
+		if uint64(len(history)) >= limit {
+			break
+		}
+
+		ts := timestamp(entry)
+		if params.BlockStart != 0 && entry.Block < params.BlockStart {
+			continue
+		}
+		if params.BlockEnd != 0 && entry.Block > params.BlockEnd {
+			continue
+		}
+		if params.TimestampStart != 0 && ts < params.TimestampStart {
+			continue
+		}
+		if params.TimestampEnd != 0 && ts > params.TimestampEnd {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		history = append(history, sse.EventHistory{
+			Block:     entry.Block,
+			Timestamp: ts,
+			Hint:      entry.Event,
+		})
+	}
+
+	return history, nil
+}
+
+// timestamp returns the unix timestamp of the entry, zero if unknown
+func timestamp(entry Entry) uint64 {
+	if entry.Time.IsZero() {
+		return 0
+	}
+
+	return uint64(entry.Time.Unix())
+}
+
+// subscription stops a replay
+type subscription struct {
+	stopper  chan struct{}
+	stopOnce sync.Once
+}
+
+// Stop stops the replay
+func (s *subscription) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopper)
+	})
+}
@@ -0,0 +1,181 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/duoxehyon/mev-share-go/sse/store"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(b byte) sse.MatchMakerEvent {
+	return sse.MatchMakerEvent{Hash: common.BytesToHash([]byte{b})}
+}
+
+func receive(t *testing.T, client sse.SSEClient) []common.Hash {
+	events := make(chan sse.Event)
+	_, err := client.Subscribe(events)
+	require.NoError(t, err)
+
+	var hashes []common.Hash
+	for event := range events {
+		require.NoError(t, event.Error)
+		hashes = append(hashes, event.Data.Hash)
+	}
+	return hashes
+}
+
+func TestLoad_Formats(t *testing.T) {
+	dir := t.TempDir()
+
+	history := filepath.Join(dir, "history.json")
+	require.NoError(t, os.WriteFile(history, []byte(` [
+		{"block":10,"timestamp":1700000000,"hint":{"hash":"0x0000000000000000000000000000000000000000000000000000000000000001"}}
+	]`), 0o600))
+
+	lines := filepath.Join(dir, "events.jsonl")
+	require.NoError(t, os.WriteFile(lines, []byte(strings.Join([]string{
+		`{"id":1,"receivedAt":"2023-11-14T22:13:20Z","event":{"hash":"0x0000000000000000000000000000000000000000000000000000000000000001"}}`,
+		``,
+		`{"block":11,"timestamp":1700000012,"hint":{"hash":"0x0000000000000000000000000000000000000000000000000000000000000002"}}`,
+		`{"hash":"0x0000000000000000000000000000000000000000000000000000000000000003"}`,
+	}, "\n")), 0o600))
+
+	entries, err := Load(history)
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Time: time.Unix(1700000000, 0), Block: 10, Event: testEvent(1), NodeTime: time.Unix(1700000000, 0)}}, entries)
+
+	entries, err = Load(lines)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), entries[0].Time)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), entries[0].ReceivedAt)
+	assert.Equal(t, uint64(11), entries[1].Block)
+	assert.Equal(t, Entry{Event: testEvent(3)}, entries[2])
+
+	require.NoError(t, os.WriteFile(lines, []byte("{\"hash\":\n"), 0o600))
+	_, err = Load(lines)
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestOpen_Store(t *testing.T) {
+	dir := t.TempDir()
+	w, err := store.NewWriter(dir)
+	require.NoError(t, err)
+	received := time.Unix(1700000000, 0).UTC()
+	for i := byte(1); i <= 2; i++ {
+		event := testEvent(i)
+		_, err := w.Write(&event, received.Add(time.Duration(i-1)*time.Millisecond))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	client, err := Open(dir, WithSpeed(0))
+	require.NoError(t, err)
+	assert.Equal(t, []common.Hash{testEvent(1).Hash, testEvent(2).Hash}, receive(t, client))
+
+	// The stored receive time is kept
+	events := make(chan sse.Event)
+	sub, err := client.Subscribe(events)
+	require.NoError(t, err)
+	event := <-events
+	assert.Equal(t, received, event.ReceivedAt)
+	sub.Stop()
+	for range events {
+	}
+}
+
+func TestClient_Timing(t *testing.T) {
+	start := time.Now()
+	entries := []Entry{
+		{Time: start, Event: testEvent(1)},
+		{Event: testEvent(2)},
+		{Time: start.Add(500 * time.Millisecond), Event: testEvent(3)},
+	}
+
+	began := time.Now()
+	assert.Len(t, receive(t, New(entries, WithSpeed(10))), 3)
+	assert.GreaterOrEqual(t, time.Since(began), 50*time.Millisecond)
+
+	began = time.Now()
+	assert.Len(t, receive(t, New(entries, WithSpeed(0))), 3)
+	assert.Less(t, time.Since(began), 50*time.Millisecond)
+
+	// Stopping interrupts the wait for the next entry
+	events := make(chan sse.Event)
+	sub, err := New(entries).Subscribe(events)
+	require.NoError(t, err)
+	<-events
+	<-events
+	sub.Stop()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestClient_History(t *testing.T) {
+	var entries []Entry
+	for i := byte(1); i <= 5; i++ {
+		entries = append(entries, Entry{Time: time.Unix(100*int64(i), 0), Block: uint64(i), Event: testEvent(i)})
+	}
+	client := New(entries, WithMaxLimit(2))
+
+	info, err := client.EventHistoryInfo()
+	require.NoError(t, err)
+	assert.Equal(t, sse.EventHistoryInfo{Count: 5, MinBlock: 1, MaxBlock: 5, MinTimestamp: 100, MaxLimit: 2}, *info)
+
+	history, err := client.GetEventHistory(sse.EventHistoryParams{BlockStart: 2})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, sse.EventHistory{Block: 2, Timestamp: 200, Hint: testEvent(2)}, history[0])
+
+	history, err = client.GetEventHistory(sse.EventHistoryParams{BlockStart: 2, OffSet: 2})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, uint64(4), history[0].Block)
+
+	history, err = client.GetEventHistory(sse.EventHistoryParams{TimestampEnd: 100})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	// The smaller of the requested limit and the max limit
+	history, err = client.GetEventHistory(sse.EventHistoryParams{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	history, err = client.GetEventHistory(sse.EventHistoryParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	info, err = New(entries, WithMaxLimit(0)).EventHistoryInfo()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(DefaultMaxLimit), info.MaxLimit)
+}
+
+func TestClient_EventTimes(t *testing.T) {
+	received := time.Unix(1700000000, 0)
+	client := New([]Entry{
+		{Time: received, ReceivedAt: received, Event: testEvent(1)},
+		{Time: received, NodeTime: received.Add(-time.Second), Block: 2, Event: testEvent(2)},
+	}, WithSpeed(0))
+
+	events := make(chan sse.Event)
+	_, err := client.Subscribe(events)
+	require.NoError(t, err)
+
+	recorded := <-events
+	assert.Equal(t, received, recorded.ReceivedAt)
+	assert.True(t, recorded.NodeTime.IsZero())
+
+	// Without a recorded receive time the event is received when it is sent
+	sent := <-events
+	assert.WithinDuration(t, time.Now(), sent.ReceivedAt, time.Second)
+	assert.Equal(t, received.Add(-time.Second), sent.NodeTime)
+	for range events {
+	}
+}
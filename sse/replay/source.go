@@ -0,0 +1,182 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/duoxehyon/mev-share-go/sse/store"
+)
+
+// Entry is a recorded event
+type Entry struct {
+	Time       time.Time // When the event was received or emitted, zero if unknown
+	Block      uint64    // Block of the event, zero if unknown
+	Event      sse.MatchMakerEvent
+	ReceivedAt time.Time // When the event was read from the stream, zero if not recorded
+	NodeTime   time.Time // When the node emitted the event, zero if not recorded
+}
+
+// Load reads the entries of a recording, in recorded order. The path may be
+//   - a directory written by sse/store
+//   - a JSON array of sse.EventHistory, as returned by GetEventHistory
+//   - a JSONL file of store records, sse.EventHistory or bare sse.MatchMakerEvent lines
+//
+// Files ending in .gz are decompressed.
+func Load(path string) ([]Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return loadStore(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	entries, err := Read(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Read reads the entries of a JSON history array or JSONL recording, see Load
+func Read(reader io.Reader) ([]Entry, error) {
+	buffered := bufio.NewReader(reader)
+
+	first, err := peekNonSpace(buffered)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if first == '[' {
+		var history []sse.EventHistory
+		if err := json.NewDecoder(buffered).Decode(&history); err != nil {
+			return nil, err
+		}
+
+		entries := make([]Entry, 0, len(history))
+		for _, h := range history {
+			entries = append(entries, historyEntry(h))
+		}
+		return entries, nil
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(buffered)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		entry, err := decodeLine(data)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// decodeLine decodes a store record, a history entry or a bare event
+func decodeLine(data []byte) (Entry, error) {
+	var probe struct {
+		Event json.RawMessage `json:"event"`
+		Hint  json.RawMessage `json:"hint"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return Entry{}, err
+	}
+
+	switch {
+	case probe.Event != nil:
+		var record store.Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return Entry{}, err
+		}
+		return recordEntry(record), nil
+	case probe.Hint != nil:
+		var history sse.EventHistory
+		if err := json.Unmarshal(data, &history); err != nil {
+			return Entry{}, err
+		}
+		return historyEntry(history), nil
+	default:
+		var event sse.MatchMakerEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return Entry{}, err
+		}
+		return Entry{Event: event}, nil
+	}
+}
+
+func loadStore(dir string) ([]Entry, error) {
+	reader, err := store.OpenReader(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	err = reader.Range(func(record store.Record) error {
+		entries = append(entries, recordEntry(record))
+		return nil
+	})
+	return entries, err
+}
+
+func recordEntry(record store.Record) Entry {
+	entry := Entry{Time: record.ReceivedAt, ReceivedAt: record.ReceivedAt}
+	if record.Event != nil {
+		entry.Event = *record.Event
+	}
+	return entry
+}
+
+func historyEntry(h sse.EventHistory) Entry {
+	entry := Entry{Block: h.Block, Event: h.Hint}
+	if h.Timestamp != 0 {
+		entry.Time = time.Unix(int64(h.Timestamp), 0)
+		entry.NodeTime = entry.Time
+	}
+	return entry
+}
+
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return b, reader.UnreadByte()
+		}
+	}
+}
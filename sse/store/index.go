@@ -0,0 +1,204 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// IndexFile is the name of the index in a store directory
+const IndexFile = "index.json"
+
+// Segment describes one file of the store
+type Segment struct {
+	File    string    `json:"file"`
+	FirstID uint64    `json:"firstId,omitempty"`
+	LastID  uint64    `json:"lastId,omitempty"`
+	Count   uint64    `json:"count"`
+	Start   time.Time `json:"start"`  // Receive time of the first record
+	End     time.Time `json:"end"`    // Receive time of the last record
+	Size    int64     `json:"size"`   // Compressed size as of the last sync
+	Closed  bool      `json:"closed"` // False while the file is written, or after a crash until recovered
+}
+
+// observe updates the segment with a record written to its file
+func (s *Segment) observe(record Record) {
+	if s.Count == 0 {
+		s.FirstID = record.ID
+		s.Start = record.ReceivedAt
+	}
+	s.LastID = record.ID
+	s.End = record.ReceivedAt
+	s.Count++
+}
+
+// index lists the files of the store in write order.
+// It is replaced atomically so a crash leaves either the old or the new version.
+type index struct {
+	Segments []*Segment `json:"segments"`
+}
+
+func loadIndex(dir string) (*index, error) {
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &index{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var idx index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("%s: %w", IndexFile, err)
+	}
+	return &idx, nil
+}
+
+// save writes the index to a temporary file and renames it over the old one
+func (idx *index) save(dir string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, IndexFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, IndexFile)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// segmentFile returns the file name of the nth segment
+func segmentFile(n int) string {
+	return fmt.Sprintf("events-%06d.jsonl.gz", n)
+}
+
+// add appends a segment for a new file
+func (idx *index) add(now time.Time) *Segment {
+	seg := &Segment{
+		File:  segmentFile(len(idx.Segments) + 1),
+		Start: now,
+		End:   now,
+	}
+	idx.Segments = append(idx.Segments, seg)
+	return seg
+}
+
+// removeLast removes the segment of a file that could not be started
+func (idx *index) removeLast() {
+	idx.Segments = idx.Segments[:len(idx.Segments)-1]
+}
+
+// nextID returns the id for the next record
+func (idx *index) nextID() uint64 {
+	for i := len(idx.Segments) - 1; i >= 0; i-- {
+		if idx.Segments[i].Count > 0 {
+			return idx.Segments[i].LastID + 1
+		}
+	}
+	return 1
+}
+
+// recover rewrites files that were not closed with their complete records, dropping a tail
+// cut off or corrupted by a crash, and closes them. Files following the last segment that are
+// not listed, created by a crash before the index was saved, are adopted.
+func (idx *index) recover(dir string) error {
+	recovered := false
+	for {
+		seg := &Segment{File: segmentFile(len(idx.Segments) + 1)}
+		if _, err := os.Stat(filepath.Join(dir, seg.File)); errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return err
+		}
+		idx.Segments = append(idx.Segments, seg)
+	}
+
+	for _, seg := range idx.Segments {
+		if seg.Closed {
+			continue
+		}
+
+		recount, err := rewriteSegment(dir, seg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", seg.File, err)
+		}
+		*seg = recount
+		recovered = true
+	}
+
+	if !recovered {
+		return nil
+	}
+	return idx.save(dir)
+}
+
+// rewriteSegment copies the good records of the file to a new one and renames it over the file.
+// A file that was listed but never created is replaced by an empty one.
+func rewriteSegment(dir string, seg *Segment) (Segment, error) {
+	recount := Segment{File: seg.File, Start: seg.Start, End: seg.End, Closed: true}
+
+	path := filepath.Join(dir, seg.File)
+	_, err := os.Stat(path)
+	missing := errors.Is(err, os.ErrNotExist)
+	if err != nil && !missing {
+		return recount, err
+	}
+
+	tmp := path + ".recover"
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return recount, err
+	}
+	rewritten, err := createSegment(tmp, &recount, seg.Start)
+	if err != nil {
+		return recount, err
+	}
+
+	if !missing {
+		err = readSegment(path, true, rewritten.write)
+	}
+	if closeErr := rewritten.close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return recount, err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return recount, err
+	}
+	return recount, syncDir(dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Not every platform supports fsync on directories
+	_ = d.Sync()
+	return nil
+}
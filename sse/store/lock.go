@@ -0,0 +1,24 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LockFile is the name of the lock of a store directory, a Writer holds it while open
+const LockFile = "LOCK"
+
+// lockDir takes the exclusive lock of the store in dir, closing the file releases it.
+// The lock is released by the operating system when the process dies, so a crash never leaves it behind.
+func lockDir(dir string) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Join(dir, LockFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%w: %w", ErrLocked, err)
+	}
+	return file, nil
+}
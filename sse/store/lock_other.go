@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package store
+
+import "os"
+
+// lockFile does not lock on platforms without flock or LockFileEx
+func lockFile(*os.File) error {
+	return nil
+}
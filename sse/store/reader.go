@@ -0,0 +1,124 @@
+package store
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Reader reads the records of a store directory.
+// The replay package serves a store as an sse.SSEClient, see replay.Open.
+type Reader struct {
+	dir string
+}
+
+// OpenReader opens the store in dir
+func OpenReader(dir string) (*Reader, error) {
+	if _, err := os.Stat(filepath.Join(dir, IndexFile)); err != nil {
+		return nil, err
+	}
+
+	return &Reader{dir: dir}, nil
+}
+
+// Segments returns the files of the store in write order
+func (r *Reader) Segments() ([]Segment, error) {
+	idx, err := loadIndex(r.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]Segment, 0, len(idx.Segments))
+	for _, seg := range idx.Segments {
+		segments = append(segments, *seg)
+	}
+	return segments, nil
+}
+
+// Range calls fn for every record in write order until fn returns an error.
+// A file that is still written, or was left open by a crash, ends at its last good record.
+// A closed file that fails to decompress or decode is an error.
+func (r *Reader) Range(fn func(Record) error) error {
+	segments, err := r.Segments()
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range segments {
+		if err := readSegment(filepath.Join(r.dir, seg.File), !seg.Closed, fn); err != nil {
+			return fmt.Errorf("%s: %w", seg.File, err)
+		}
+	}
+	return nil
+}
+
+// readSegment calls fn for every record of the file up to the last good one.
+// If partial is set, anything that fails to decompress or decode ends the file and only read errors
+// of the file itself and errors of fn are returned, otherwise decoding errors are returned too.
+func readSegment(path string, partial bool, fn func(Record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	source := &sourceReader{r: file}
+	broken := func(err error) error {
+		if source.err != nil {
+			return source.err
+		}
+		if partial {
+			return nil
+		}
+		return err
+	}
+
+	gz, err := gzip.NewReader(source)
+	if err != nil {
+		// Created but nothing was flushed before a crash, or the header is damaged
+		return broken(err)
+	}
+	defer gz.Close()
+
+	reader := bufio.NewReader(gz)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return source.err
+		}
+		if errors.Is(err, io.EOF) {
+			// Anything without a trailing newline was cut off while writing
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return broken(err)
+		}
+
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return broken(err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// sourceReader keeps the read error of the file, to tell it apart from decompression errors
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.err = err
+	}
+	return n, err
+}
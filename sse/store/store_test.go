@@ -0,0 +1,294 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/mevsharetest"
+	"github.com/duoxehyon/mev-share-go/sse"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(b byte) *sse.MatchMakerEvent {
+	return &sse.MatchMakerEvent{
+		Hash: common.BytesToHash([]byte{b}),
+		Txs:  []sse.PendingTransaction{{To: common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")}},
+	}
+}
+
+// crash abandons the writer, releasing its lock as the operating system does when the process dies
+func crash(t *testing.T, w *Writer) {
+	require.NoError(t, w.lock.Close())
+}
+
+func readAll(t *testing.T, dir string) []Record {
+	reader, err := OpenReader(dir)
+	require.NoError(t, err)
+
+	var records []Record
+	require.NoError(t, reader.Range(func(record Record) error {
+		records = append(records, record)
+		return nil
+	}))
+	return records
+}
+
+func TestWriter_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir, WithMaxSize(1), WithSyncInterval(0))
+	require.NoError(t, err)
+
+	start := time.Unix(1700000000, 0).UTC()
+	for i := 0; i < 3; i++ {
+		record, err := w.Write(testEvent(byte(i)), start.Add(time.Duration(i)*time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(i+1), record.ID)
+	}
+	require.NoError(t, w.Close())
+
+	_, err = w.Write(testEvent(9), start)
+	assert.ErrorIs(t, err, ErrClosed)
+
+	reader, err := OpenReader(dir)
+	require.NoError(t, err)
+	segments, err := reader.Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+	assert.Equal(t, "events-000002.jsonl.gz", segments[1].File)
+	assert.Equal(t, uint64(2), segments[1].FirstID)
+	assert.Equal(t, start.Add(time.Second), segments[1].Start)
+	assert.True(t, segments[2].Closed)
+
+	records := readAll(t, dir)
+	require.Len(t, records, 3)
+	assert.Equal(t, testEvent(2), records[2].Event)
+	assert.Equal(t, start.Add(2*time.Second), records[2].ReceivedAt)
+}
+
+func TestWriter_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir, WithMaxAge(time.Minute))
+	require.NoError(t, err)
+
+	start := time.Now()
+	for _, offset := range []time.Duration{0, time.Second, 2 * time.Minute} {
+		_, err := w.Write(testEvent(1), start.Add(offset))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	segments, err := (&Reader{dir: dir}).Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	assert.Equal(t, uint64(2), segments[0].Count)
+}
+
+func TestWriter_Recover(t *testing.T) {
+	dir := t.TempDir()
+	crashed, err := NewWriter(dir, WithSyncInterval(time.Hour))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := crashed.Write(testEvent(byte(i)), time.Now())
+		require.NoError(t, err)
+	}
+	require.NoError(t, crashed.Sync())
+	// Never synced, lost with the crash
+	_, err = crashed.Write(testEvent(2), time.Now())
+	require.NoError(t, err)
+	crash(t, crashed)
+
+	w, err := NewWriter(dir, WithSyncInterval(time.Hour))
+	require.NoError(t, err)
+	record, err := w.Write(testEvent(3), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), record.ID)
+	require.NoError(t, w.Close())
+
+	segments, err := (&Reader{dir: dir}).Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	assert.True(t, segments[0].Closed)
+	assert.Equal(t, uint64(2), segments[0].Count)
+
+	records := readAll(t, dir)
+	require.Len(t, records, 3)
+	assert.Equal(t, testEvent(3), records[2].Event)
+}
+
+func TestWriter_Consume(t *testing.T) {
+	node := mevsharetest.NewNode(mevsharetest.WithScript(*testEvent(1), *testEvent(2)))
+	defer node.Close()
+
+	dir := t.TempDir()
+	w, err := NewWriter(dir)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Consume(ctx, sse.New(node.URL)) }()
+
+	assert.Eventually(t, func() bool {
+		return w.Sync() == nil && len(readAll(t, dir)) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	require.NoError(t, w.Close())
+}
+
+func TestWriter_RecoverCorruptTail(t *testing.T) {
+	dir := t.TempDir()
+	crashed, err := NewWriter(dir, WithSyncInterval(time.Hour))
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err := crashed.Write(testEvent(byte(i)), time.Now())
+		require.NoError(t, err)
+	}
+	require.NoError(t, crashed.Sync())
+	crash(t, crashed)
+
+	segments, err := (&Reader{dir: dir}).Segments()
+	require.NoError(t, err)
+	file, err := os.OpenFile(filepath.Join(dir, segments[0].File), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = file.Write([]byte("\x00garbage\xff\xfe\n{not a record}\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	// The reader stops at the last good record
+	require.Len(t, readAll(t, dir), 2)
+
+	w, err := NewWriter(dir, WithSyncInterval(0))
+	require.NoError(t, err)
+	record, err := w.Write(testEvent(2), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), record.ID)
+	require.NoError(t, w.Close())
+
+	segments, err = (&Reader{dir: dir}).Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	assert.True(t, segments[0].Closed)
+	assert.Equal(t, uint64(2), segments[0].Count)
+
+	// The recovered file was rewritten as a complete gzip file
+	data, err := os.ReadFile(filepath.Join(dir, segments[0].File))
+	require.NoError(t, err)
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	_, err = io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), segments[0].Size)
+
+	records := readAll(t, dir)
+	require.Len(t, records, 3)
+	assert.Equal(t, testEvent(2), records[2].Event)
+}
+
+func TestWriter_RecoverUncreatedFile(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir, WithSyncInterval(0))
+	require.NoError(t, err)
+	_, err = w.Write(testEvent(1), time.Now())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// A crash after the next file was listed but before it was created
+	idx, err := loadIndex(dir)
+	require.NoError(t, err)
+	idx.add(time.Now())
+	require.NoError(t, idx.save(dir))
+
+	w, err = NewWriter(dir, WithSyncInterval(0))
+	require.NoError(t, err)
+	record, err := w.Write(testEvent(2), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), record.ID)
+	require.NoError(t, w.Close())
+
+	segments, err := (&Reader{dir: dir}).Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+	assert.True(t, segments[1].Closed)
+	assert.Zero(t, segments[1].Count)
+
+	records := readAll(t, dir)
+	require.Len(t, records, 2)
+	assert.Equal(t, testEvent(2), records[1].Event)
+}
+
+func TestWriter_RecoverUnlistedFile(t *testing.T) {
+	dir := t.TempDir()
+	crashed, err := NewWriter(dir, WithSyncInterval(0))
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err := crashed.Write(testEvent(byte(i)), time.Now())
+		require.NoError(t, err)
+	}
+	crash(t, crashed)
+
+	// A file created without being listed, as a crash before the index was saved left it
+	idx, err := loadIndex(dir)
+	require.NoError(t, err)
+	idx.removeLast()
+	require.NoError(t, idx.save(dir))
+
+	w, err := NewWriter(dir, WithSyncInterval(0))
+	require.NoError(t, err)
+	record, err := w.Write(testEvent(2), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), record.ID)
+	require.NoError(t, w.Close())
+
+	segments, err := (&Reader{dir: dir}).Segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	assert.True(t, segments[0].Closed)
+	assert.Equal(t, uint64(2), segments[0].Count)
+	require.Len(t, readAll(t, dir), 3)
+}
+
+func TestReader_CorruptClosedFile(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir)
+	require.NoError(t, err)
+	_, err = w.Write(testEvent(1), time.Now())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	segments, err := (&Reader{dir: dir}).Segments()
+	require.NoError(t, err)
+	path := filepath.Join(dir, segments[0].File)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data[:len(data)-4], 0o644))
+
+	reader, err := OpenReader(dir)
+	require.NoError(t, err)
+	err = reader.Range(func(Record) error { return nil })
+	assert.ErrorContains(t, err, segments[0].File)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestWriter_Lock(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir)
+	require.NoError(t, err)
+
+	_, err = NewWriter(dir)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, w.Close())
+	w, err = NewWriter(dir)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
@@ -0,0 +1,376 @@
+// Package store archives matchmaker events to rotating, gzip compressed JSONL files
+package store
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/duoxehyon/mev-share-go/sse"
+)
+
+const (
+	// DefaultMaxSize is the default compressed size after which a file is rotated
+	DefaultMaxSize = 64 << 20
+	// DefaultMaxAge is the default age after which a file is rotated
+	DefaultMaxAge = time.Hour
+	// DefaultSyncInterval is the default cadence for flushing and fsyncing the current file
+	DefaultSyncInterval = time.Second
+)
+
+// ErrClosed is returned when writing to a closed Writer
+var ErrClosed = errors.New("store closed")
+
+// ErrLocked is returned by NewWriter when another Writer has the store open
+var ErrLocked = errors.New("store locked by another writer")
+
+// Record is a stored event
+type Record struct {
+	ID         uint64               `json:"id"`
+	ReceivedAt time.Time            `json:"receivedAt"`
+	Event      *sse.MatchMakerEvent `json:"event"`
+}
+
+// Writer appends records to the files of a store directory
+type Writer struct {
+	dir          string
+	maxSize      int64
+	maxAge       time.Duration
+	syncInterval time.Duration
+
+	lock    *os.File
+	mu      sync.Mutex
+	index   *index
+	current *segmentWriter
+	dirty   bool
+	closed  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Option configures a Writer
+type Option func(*Writer)
+
+// WithMaxSize rotates files once their compressed size reaches size bytes
+func WithMaxSize(size int64) Option {
+	return func(w *Writer) {
+		w.maxSize = size
+	}
+}
+
+// WithMaxAge rotates files once they are older than age
+func WithMaxAge(age time.Duration) Option {
+	return func(w *Writer) {
+		w.maxAge = age
+	}
+}
+
+// WithSyncInterval sets how often the current file is flushed and fsynced,
+// 0 syncs after every record
+func WithSyncInterval(interval time.Duration) Option {
+	return func(w *Writer) {
+		w.syncInterval = interval
+	}
+}
+
+// NewWriter opens the store in dir, creating it if needed, and locks it until Close.
+// Files left open by a crash are recovered and writing continues in a new file.
+func NewWriter(dir string, opts ...Option) (*Writer, error) {
+	w := &Writer{
+		dir:          dir,
+		maxSize:      DefaultMaxSize,
+		maxAge:       DefaultMaxAge,
+		syncInterval: DefaultSyncInterval,
+		done:         make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	lock, err := lockDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := loadIndex(dir)
+	if err == nil {
+		err = idx.recover(dir)
+	}
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	w.lock = lock
+	w.index = idx
+
+	if w.syncInterval > 0 {
+		w.wg.Add(1)
+		go w.syncLoop()
+	}
+
+	return w, nil
+}
+
+// Write appends the event and returns its record
+func (w *Writer) Write(event *sse.MatchMakerEvent, receivedAt time.Time) (Record, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return Record{}, ErrClosed
+	}
+
+	if w.current != nil && w.current.full(w.maxSize, w.maxAge, receivedAt) {
+		if err := w.rotate(); err != nil {
+			return Record{}, err
+		}
+	}
+	if w.current == nil {
+		if err := w.open(receivedAt); err != nil {
+			return Record{}, err
+		}
+	}
+
+	record := Record{
+		ID:         w.index.nextID(),
+		ReceivedAt: receivedAt,
+		Event:      event,
+	}
+	if err := w.current.write(record); err != nil {
+		return Record{}, err
+	}
+	w.dirty = true
+
+	if w.syncInterval == 0 {
+		return record, w.sync()
+	}
+	return record, nil
+}
+
+// Consume writes the events of the client's subscription until ctx is done or the stream ends.
+// Events that failed to decode are skipped.
+func (w *Writer) Consume(ctx context.Context, client sse.SSEClient) error {
+	events := make(chan sse.Event, 64)
+	sub, err := client.Subscribe(events)
+	if err != nil {
+		return err
+	}
+	defer sub.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return sse.ErrSubscriptionClosed
+			}
+			if event.Error != nil {
+				continue
+			}
+			receivedAt := event.ReceivedAt
+			if receivedAt.IsZero() {
+				receivedAt = time.Now()
+			}
+			if _, err := w.Write(event.Data, receivedAt); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Sync flushes the current file, fsyncs it and updates the index
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+	return w.sync()
+}
+
+// Close closes the current file and the writer
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+
+	var err error
+	if w.current != nil {
+		err = w.rotate()
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	if lockErr := w.lock.Close(); err == nil {
+		err = lockErr
+	}
+	return err
+}
+
+func (w *Writer) syncLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if !w.closed {
+				// A failed sync is retried on the next tick, Write and Close surface persistent failures
+				_ = w.sync()
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+// sync flushes the current file and saves the index, w.mu must be held
+func (w *Writer) sync() error {
+	if w.current == nil || !w.dirty {
+		return nil
+	}
+
+	if err := w.current.flush(); err != nil {
+		return err
+	}
+	w.dirty = false
+
+	return w.index.save(w.dir)
+}
+
+// open starts a new file, w.mu must be held
+func (w *Writer) open(now time.Time) error {
+	seg := w.index.add(now)
+
+	// The file is listed before it is created so a crash never leaves it unindexed,
+	// recovery closes a listed file that was never created
+	if err := w.index.save(w.dir); err != nil {
+		w.index.removeLast()
+		return err
+	}
+
+	current, err := createSegment(filepath.Join(w.dir, seg.File), seg, now)
+	if err != nil {
+		w.index.removeLast()
+		return err
+	}
+	w.current = current
+	return nil
+}
+
+// rotate closes the current file, w.mu must be held
+func (w *Writer) rotate() error {
+	current := w.current
+	w.current = nil
+	w.dirty = false
+
+	if err := current.close(); err != nil {
+		return fmt.Errorf("%s: %w", current.seg.File, err)
+	}
+	current.seg.Closed = true
+
+	return w.index.save(w.dir)
+}
+
+// segmentWriter writes the records of one file
+type segmentWriter struct {
+	seg     *Segment
+	file    *os.File
+	counter *countingWriter
+	gz      *gzip.Writer
+	opened  time.Time
+}
+
+func createSegment(path string, seg *Segment, now time.Time) (*segmentWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := &countingWriter{w: file}
+	return &segmentWriter{
+		seg:     seg,
+		file:    file,
+		counter: counter,
+		gz:      gzip.NewWriter(counter),
+		opened:  now,
+	}, nil
+}
+
+// full reports whether the file should be rotated before writing a record received at now
+func (s *segmentWriter) full(maxSize int64, maxAge time.Duration, now time.Time) bool {
+	return (maxSize > 0 && s.counter.n >= maxSize) || (maxAge > 0 && now.Sub(s.opened) >= maxAge)
+}
+
+func (s *segmentWriter) write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.gz.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	s.seg.observe(record)
+	return nil
+}
+
+func (s *segmentWriter) flush() error {
+	if err := s.gz.Flush(); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+
+	s.seg.Size = s.counter.n
+	return nil
+}
+
+func (s *segmentWriter) close() error {
+	if err := s.gz.Close(); err != nil {
+		s.file.Close()
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return err
+	}
+
+	s.seg.Size = s.counter.n
+	return s.file.Close()
+}
+
+// countingWriter counts the bytes written to the file
+type countingWriter struct {
+	w interface{ Write([]byte) (int, error) }
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
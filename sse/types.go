@@ -1,19 +1,56 @@
 package sse
 
 import (
-	"encoding/hex"
+	"context"
 	"encoding/json"
-	"strings"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/duoxehyon/mev-share-go/types"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
+// DecodeMode controls how malformed hint fields are handled, see types.DecodeMode
+type DecodeMode = types.DecodeMode
+
+const (
+	// Lenient leaves malformed fields empty and records them in MatchMakerEvent.Warnings
+	Lenient = types.Lenient
+	// Strict fails on the first malformed field with a *FieldError
+	Strict = types.Strict
+	// DefaultDecodeMode is the mode of clients without WithDecodeMode and of the UnmarshalJSON methods,
+	// see types.DefaultDecodeMode
+	DefaultDecodeMode = types.DefaultDecodeMode
+)
+
+// FieldError is a malformed hint field, see types.FieldError
+type FieldError = types.FieldError
+
+// ErrSelectorLength is reported for function selectors that are not 4 bytes long
+var ErrSelectorLength = errors.New("function selector must be 4 bytes")
+
 // Event represents a matchmaker event sent from sse subscription
 type Event struct {
-	Data  *MatchMakerEvent // Will be nil if an error occurred during poll
-	Error error
+	Data       *MatchMakerEvent // Will be nil if an error occurred during poll
+	Error      error
+	ReceivedAt time.Time     // When the event was read from the stream, zero if it was not. Carries a monotonic clock reading.
+	NodeTime   time.Time     // When the node emitted the event. The live stream does not say, only backfilled and replayed events carry it.
+	Latency    time.Duration // ReceivedAt behind NodeTime or the latest block, zero unless latency tracking is enabled
+
+	ctx context.Context
+}
+
+// Context returns a context carrying the span of the received hint, to trace the work done for it
+// as its children. It is the background context unless tracing is enabled.
+func (e Event) Context() context.Context {
+	if e.ctx != nil {
+		return e.ctx
+	}
+
+	return context.Background()
 }
 
 // MatchMakerEvent represents the pending transaction hints sent by matchmaker
@@ -23,6 +60,67 @@ type MatchMakerEvent struct {
 	Txs         []PendingTransaction `json:"txs,omitempty"`
 	MevGasPrice *hexutil.Big         `json:"mevGasPrice,omitempty"`
 	GasUsed     *hexutil.Big         `json:"gasUsed,omitempty"`
+
+	// Warnings lists the malformed fields left empty while decoding in lenient mode
+	Warnings []*FieldError `json:"-"`
+}
+
+// DecodeEvent decodes a matchmaker event, see DecodeMode
+func DecodeEvent(data []byte, mode DecodeMode) (*MatchMakerEvent, error) {
+	var temp struct {
+		Hash        json.RawMessage   `json:"hash"`
+		Logs        []json.RawMessage `json:"logs"`
+		Txs         []json.RawMessage `json:"txs"`
+		MevGasPrice json.RawMessage   `json:"mevGasPrice"`
+		GasUsed     json.RawMessage   `json:"gasUsed"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return nil, err
+	}
+
+	d := &types.Decoder{Mode: mode}
+	event := &MatchMakerEvent{}
+
+	if err := d.Field("hash", temp.Hash, &event.Hash); err != nil {
+		return nil, err
+	}
+
+	for i, raw := range temp.Logs {
+		log, err := d.Log(fmt.Sprintf("logs[%d]", i), raw)
+		if err != nil {
+			return nil, err
+		}
+		event.Logs = append(event.Logs, log)
+	}
+
+	for i, raw := range temp.Txs {
+		var tx PendingTransaction
+		if err := tx.decode(d, fmt.Sprintf("txs[%d]", i), raw); err != nil {
+			return nil, err
+		}
+		event.Txs = append(event.Txs, tx)
+	}
+
+	if err := d.Field("mevGasPrice", temp.MevGasPrice, &event.MevGasPrice); err != nil {
+		return nil, err
+	}
+	if err := d.Field("gasUsed", temp.GasUsed, &event.GasUsed); err != nil {
+		return nil, err
+	}
+
+	event.Warnings = d.Warnings
+	return event, nil
+}
+
+// UnmarshalJSON unmarshals JSON data into a MatchMakerEvent in DefaultDecodeMode
+func (e *MatchMakerEvent) UnmarshalJSON(data []byte) error {
+	event, err := DecodeEvent(data, DefaultDecodeMode)
+	if err != nil {
+		return err
+	}
+
+	*e = *event
+	return nil
 }
 
 // PendingTransaction represents the hits revealed by the matchmaker about the transaction / bundle
@@ -34,36 +132,100 @@ type PendingTransaction struct {
 	GasUsed          *hexutil.Big   `json:"gasUsed,omitempty"`
 }
 
-// UnmarshalJSON unmarshals JSON data into a PendingTransaction
+// UnmarshalJSON unmarshals JSON data into a PendingTransaction in DefaultDecodeMode,
+// use DecodeEvent to see the warnings or to fail on malformed fields
 func (t *PendingTransaction) UnmarshalJSON(data []byte) error {
+	var tx PendingTransaction
+	if err := tx.decode(&types.Decoder{Mode: DefaultDecodeMode}, "", data); err != nil {
+		return err
+	}
+
+	*t = tx
+	return nil
+}
+
+// decode decodes the transaction at path
+func (t *PendingTransaction) decode(d *types.Decoder, path string, data []byte) error {
 	var temp struct {
-		To               common.Address `json:"to"`
-		FunctionSelector string         `json:"functionSelector,omitempty"`
-		CallData         string         `json:"callData,omitempty"`
-		MevGasPrice      *hexutil.Big   `json:"mevGasPrice,omitempty"`
-		GasUsed          *hexutil.Big   `json:"gasUsed,omitempty"`
+		To               json.RawMessage `json:"to"`
+		FunctionSelector json.RawMessage `json:"functionSelector"`
+		CallData         json.RawMessage `json:"callData"`
+		MevGasPrice      json.RawMessage `json:"mevGasPrice"`
+		GasUsed          json.RawMessage `json:"gasUsed"`
 	}
 	if err := json.Unmarshal(data, &temp); err != nil {
+		return &FieldError{Path: path, Value: string(data), Err: err}
+	}
+
+	if err := d.Field(types.JoinPath(path, "to"), temp.To, &t.To); err != nil {
 		return err
 	}
 
-	t.MevGasPrice = temp.MevGasPrice
-	t.GasUsed = temp.GasUsed
-	t.To = temp.To
+	callData, err := d.Hex(types.JoinPath(path, "callData"), temp.CallData)
+	if err != nil {
+		return err
+	}
+	if len(callData) > 0 {
+		t.CallData = callData
+	}
 
-	if temp.CallData != "" && temp.CallData != "0x" {
-		decoded, err := hex.DecodeString(strings.TrimPrefix(temp.CallData, "0x"))
-		if err == nil {
-			t.CallData = decoded
+	selectorPath := types.JoinPath(path, "functionSelector")
+	selector, err := d.Hex(selectorPath, temp.FunctionSelector)
+	if err != nil {
+		return err
+	}
+	switch len(selector) {
+	case 0:
+	case 4:
+		copy(t.FunctionSelector[:], selector)
+	default:
+		// Left empty, a truncated selector would match a function the transaction does not call
+		if err := d.Report(selectorPath, string(temp.FunctionSelector), ErrSelectorLength); err != nil {
+			return err
 		}
 	}
 
-	if temp.FunctionSelector != "" && temp.FunctionSelector != "0x" {
-		decoded, err := hex.DecodeString(strings.TrimPrefix(temp.FunctionSelector, "0x"))
-		if err == nil && len(decoded) >= 4 {
-			copy(t.FunctionSelector[:], decoded[:4])
-		}
+	if err := d.Field(types.JoinPath(path, "mevGasPrice"), temp.MevGasPrice, &t.MevGasPrice); err != nil {
+		return err
+	}
+	return d.Field(types.JoinPath(path, "gasUsed"), temp.GasUsed, &t.GasUsed)
+}
+
+// MarshalJSON marshals the PendingTransaction with hex encoded selector and calldata,
+// fields that were not revealed are omitted the way the matchmaker does
+func (t PendingTransaction) MarshalJSON() ([]byte, error) {
+	temp := struct {
+		To               *common.Address `json:"to,omitempty"`
+		FunctionSelector hexutil.Bytes   `json:"functionSelector,omitempty"`
+		CallData         hexutil.Bytes   `json:"callData,omitempty"`
+		MevGasPrice      *hexutil.Big    `json:"mevGasPrice,omitempty"`
+		GasUsed          *hexutil.Big    `json:"gasUsed,omitempty"`
+	}{
+		CallData:    t.CallData,
+		MevGasPrice: t.MevGasPrice,
+		GasUsed:     t.GasUsed,
 	}
 
-	return nil
+	if t.To != (common.Address{}) {
+		temp.To = &t.To
+	}
+	if t.FunctionSelector != [4]byte{} {
+		temp.FunctionSelector = t.FunctionSelector[:]
+	}
+
+	return json.Marshal(temp)
+}
+
+// ToCallMsg converts the transaction into a call message with the revealed target and calldata,
+// the sender, value and gas fields are left for the caller to fill in
+func (t *PendingTransaction) ToCallMsg() ethereum.CallMsg {
+	msg := ethereum.CallMsg{
+		Data: append([]byte(nil), t.CallData...),
+	}
+	if t.To != (common.Address{}) {
+		to := t.To
+		msg.To = &to
+	}
+
+	return msg
 }
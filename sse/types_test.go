@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -99,3 +100,101 @@ func TestEvent_Data_Success(t *testing.T) {
 	assert.NoError(t, event.Error)
 	assert.Equal(t, matchMakerEvent, event.Data)
 }
+
+func TestMatchMakerEvent_RoundTrip(t *testing.T) {
+	raw := `{"hash":"0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",` +
+		`"logs":[{"address":"0x1234567890abcdef1234567890abcdef12345678","topics":["0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"],"data":"0x"}],` +
+		`"txs":[{"to":"0x1234567890abcdef1234567890abcdef12345678","functionSelector":"0xabcdef12","callData":"0xabcdef12deadbeef"},{"functionSelector":"0x12345678"}],` +
+		`"mevGasPrice":"0x1000000000000","gasUsed":"0x200000"}`
+
+	var event MatchMakerEvent
+	assert.NoError(t, json.Unmarshal([]byte(raw), &event))
+
+	encoded, err := json.Marshal(event)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, string(encoded))
+}
+
+func TestDecodeEvent_Modes(t *testing.T) {
+	raw := []byte(`{"hash":"0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",` +
+		`"logs":[{"address":"0x1234567890abcdef1234567890abcdef12345678","data":"0x"}],` +
+		`"txs":[{"functionSelector":"0xabcdef12"},{"functionSelector":"0xab","callData":"deadbeef"}],"gasUsed":"0x200000"}`)
+
+	_, err := DecodeEvent(raw, Strict)
+	var fieldErr *FieldError
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "txs[1].callData", fieldErr.Path)
+	assert.ErrorIs(t, err, hexutil.ErrMissingPrefix)
+
+	event, err := DecodeEvent(raw, Lenient)
+	assert.NoError(t, err)
+	assert.Len(t, event.Txs, 2)
+	assert.Equal(t, [4]byte{0xab, 0xcd, 0xef, 0x12}, event.Txs[0].FunctionSelector)
+	// Unprefixed hex is accepted in lenient mode
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, event.Txs[1].CallData)
+	assert.Equal(t, big.NewInt(0x200000), event.GasUsed.ToInt())
+	if assert.Len(t, event.Warnings, 1) {
+		assert.Equal(t, "txs[1].functionSelector", event.Warnings[0].Path)
+		assert.ErrorIs(t, event.Warnings[0], ErrSelectorLength)
+	}
+	assert.Zero(t, event.Txs[1].FunctionSelector)
+
+	// An over-long selector is left empty rather than truncated
+	long, err := DecodeEvent([]byte(`{"txs":[{"functionSelector":"0xabcdef1234"}]}`), Lenient)
+	assert.NoError(t, err)
+	assert.Zero(t, long.Txs[0].FunctionSelector)
+	if assert.Len(t, long.Warnings, 1) {
+		assert.ErrorIs(t, long.Warnings[0], ErrSelectorLength)
+	}
+
+	// json.Unmarshal is lenient
+	var decoded MatchMakerEvent
+	assert.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, event, &decoded)
+
+	_, err = DecodeEvent([]byte(`{"hash":"0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"}`), Strict)
+	assert.NoError(t, err)
+}
+
+func TestPendingTransaction_ToCallMsg(t *testing.T) {
+	to := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	tx := PendingTransaction{To: to, CallData: []byte{0xab, 0xcd, 0xef, 0x12}}
+
+	msg := tx.ToCallMsg()
+	assert.Equal(t, &to, msg.To)
+	assert.Equal(t, tx.CallData, msg.Data)
+
+	msg = (&PendingTransaction{FunctionSelector: [4]byte{0xab, 0xcd, 0xef, 0x12}}).ToCallMsg()
+	assert.Nil(t, msg.To)
+	assert.Nil(t, msg.Data)
+}
+
+func FuzzPendingTransaction_RoundTrip(f *testing.F) {
+	f.Add([]byte{0x12, 0x34}, []byte{0xab, 0xcd, 0xef, 0x12}, []byte{0xde, 0xad}, uint64(0x1000), uint64(0x200))
+	f.Add([]byte{}, []byte{}, []byte{}, uint64(0), uint64(0))
+
+	f.Fuzz(func(t *testing.T, to, selector, callData []byte, mevGasPrice, gasUsed uint64) {
+		tx := PendingTransaction{To: common.BytesToAddress(to)}
+		copy(tx.FunctionSelector[:], selector)
+		if len(callData) > 0 {
+			tx.CallData = callData
+		}
+		if mevGasPrice > 0 {
+			tx.MevGasPrice = (*hexutil.Big)(new(big.Int).SetUint64(mevGasPrice))
+		}
+		if gasUsed > 0 {
+			tx.GasUsed = (*hexutil.Big)(new(big.Int).SetUint64(gasUsed))
+		}
+
+		encoded, err := json.Marshal(tx)
+		assert.NoError(t, err)
+
+		var decoded PendingTransaction
+		assert.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, tx, decoded)
+
+		reencoded, err := json.Marshal(decoded)
+		assert.NoError(t, err)
+		assert.Equal(t, string(encoded), string(reencoded))
+	})
+}
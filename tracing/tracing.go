@@ -0,0 +1,81 @@
+// Package tracing is the tracer abstraction of the sse and rpc clients.
+// The oteltracing package implements it with OpenTelemetry, so only users enabling tracing depend on it.
+package tracing
+
+import "context"
+
+// Attribute keys set by the clients
+const (
+	MethodKey      = "rpc.method"
+	TargetBlockKey = "mevshare.target_block"
+	MaxBlockKey    = "mevshare.max_block"
+	BundleHashKey  = "mevshare.bundle_hash"
+	TxHashKey      = "mevshare.tx_hash"
+	HintHashKey    = "mevshare.hint_hash"
+	LatencyKey     = "mevshare.latency_ms"
+)
+
+// Attribute is a key value pair describing a span, Value is a string, int64, uint64 or bool
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String creates a string attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int64 creates an integer attribute
+func Int64(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Uint64 creates an unsigned integer attribute, e.g. a block number
+func Uint64(key string, value uint64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool creates a boolean attribute
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer starts spans
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any, and returns a context carrying it
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is an operation being traced
+type Span interface {
+	// SetAttributes adds attributes to the span
+	SetAttributes(attrs ...Attribute)
+	// RecordError marks the span as failed with the error
+	RecordError(err error)
+	// End ends the span
+	End()
+}
+
+// Noop is a tracer that records nothing
+var Noop Tracer = noopTracer{}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// End ends the span, recording err if it is not nil
+func End(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSpan struct {
+	noopSpan
+	err   error
+	ended bool
+}
+
+func (s *recordingSpan) RecordError(err error) { s.err = err }
+func (s *recordingSpan) End()                  { s.ended = true }
+
+func TestNoop(t *testing.T) {
+	ctx := context.WithValue(context.Background(), struct{}{}, 1)
+	spanCtx, span := Noop.Start(ctx, "test", String("a", "b"))
+	assert.Equal(t, ctx, spanCtx)
+	span.SetAttributes(Uint64("block", 1))
+	End(span, errors.New("failed"))
+}
+
+func TestEnd(t *testing.T) {
+	span := &recordingSpan{}
+	End(span, nil)
+	assert.True(t, span.ended)
+	assert.NoError(t, span.err)
+
+	span = &recordingSpan{}
+	End(span, assert.AnError)
+	assert.True(t, span.ended)
+	assert.Equal(t, assert.AnError, span.err)
+}
@@ -0,0 +1,136 @@
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// DecodeMode controls how malformed hint fields are handled
+type DecodeMode int
+
+const (
+	// Lenient leaves malformed fields empty and records them as warnings
+	Lenient DecodeMode = iota
+	// Strict fails on the first malformed field
+	Strict
+)
+
+// DefaultDecodeMode is the mode of the UnmarshalJSON methods of the hint types, Log and the
+// sse package's MatchMakerEvent and PendingTransaction, and of sse clients without a decode mode set.
+// Decode with a Decoder, or sse.DecodeEvent, to choose the mode or to see the warnings.
+const DefaultDecodeMode = Lenient
+
+// FieldError is a malformed hint field
+type FieldError struct {
+	Path  string // Location of the field, e.g. "logs[0].data"
+	Value string // Raw value of the field
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: invalid value %s: %v", e.Path, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder decodes hint fields according to its mode.
+// In lenient mode malformed fields are collected in Warnings.
+type Decoder struct {
+	Mode     DecodeMode
+	Warnings []*FieldError
+}
+
+// Report handles a malformed field, it returns a *FieldError in strict mode
+// and records a warning otherwise
+func (d *Decoder) Report(path, value string, err error) error {
+	fieldErr := &FieldError{Path: path, Value: value, Err: err}
+	if d.Mode == Strict {
+		return fieldErr
+	}
+
+	d.Warnings = append(d.Warnings, fieldErr)
+	return nil
+}
+
+// Field decodes a JSON value into target, absent and null values are skipped
+func (d *Decoder) Field(path string, raw json.RawMessage, target interface{}) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, target); err != nil {
+		return d.Report(path, string(raw), err)
+	}
+	return nil
+}
+
+// Hex decodes a hex string field, strict mode requires the 0x prefix and lenient mode also accepts
+// unprefixed values. Absent and empty values decode to nil, "0x" decodes to an empty slice.
+func (d *Decoder) Hex(path string, raw json.RawMessage) ([]byte, error) {
+	var value string
+	if err := d.Field(path, raw, &value); err != nil || value == "" {
+		return nil, err
+	}
+
+	var decoded []byte
+	var err error
+	if d.Mode == Lenient && !strings.HasPrefix(value, "0x") && !strings.HasPrefix(value, "0X") {
+		decoded, err = hex.DecodeString(value)
+	} else {
+		decoded, err = hexutil.Decode(value)
+	}
+	if err != nil {
+		return nil, d.Report(path, string(raw), err)
+	}
+	return decoded, nil
+}
+
+// Log decodes the log at path
+func (d *Decoder) Log(path string, data []byte) (Log, error) {
+	var log Log
+
+	var temp struct {
+		Address json.RawMessage   `json:"address"`
+		Topics  []json.RawMessage `json:"topics"`
+		Data    json.RawMessage   `json:"data"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return log, &FieldError{Path: path, Value: string(data), Err: err}
+	}
+
+	if err := d.Field(JoinPath(path, "address"), temp.Address, &log.Address); err != nil {
+		return log, err
+	}
+
+	if temp.Topics != nil {
+		log.Topics = make([]common.Hash, 0, len(temp.Topics))
+	}
+	for i, raw := range temp.Topics {
+		var topic common.Hash
+		if err := d.Field(JoinPath(path, fmt.Sprintf("topics[%d]", i)), raw, &topic); err != nil {
+			return log, err
+		}
+		log.Topics = append(log.Topics, topic)
+	}
+
+	var err error
+	log.Data, err = d.Hex(JoinPath(path, "data"), temp.Data)
+
+	return log, err
+}
+
+// JoinPath appends a field to a path
+func JoinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+
+	return path + "." + field
+}
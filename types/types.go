@@ -2,10 +2,11 @@
 package types
 
 import (
-	"encoding/hex"
 	"encoding/json"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
 )
 
 // Log - Custom type because of hex string to bytes decoding error while using default geth.Log
@@ -15,32 +16,61 @@ type Log struct {
 	Data    []byte         `json:"data,omitempty"` // Could be replaced with geth.hexutil type
 }
 
-// UnmarshalJSON unmarshals JSON data into a Log.
+// UnmarshalJSON unmarshals JSON data into a Log in DefaultDecodeMode
 func (l *Log) UnmarshalJSON(data []byte) error {
-	var temp struct {
+	d := Decoder{Mode: DefaultDecodeMode}
+
+	log, err := d.Log("", data)
+	if err != nil {
+		return err
+	}
+
+	*l = log
+	return nil
+}
+
+// MarshalJSON marshals the Log with hex encoded data, the way the matchmaker sends it
+func (l Log) MarshalJSON() ([]byte, error) {
+	temp := struct {
 		Address common.Address `json:"address"`
 		Topics  []common.Hash  `json:"topics"`
-		Data    string         `json:"data,omitempty"`
+		Data    *hexutil.Bytes `json:"data,omitempty"`
+	}{
+		Address: l.Address,
+		Topics:  l.Topics,
 	}
-	if err := json.Unmarshal(data, &temp); err != nil {
-		return err
+
+	if l.Data != nil {
+		data := hexutil.Bytes(l.Data)
+		temp.Data = &data
 	}
 
-	l.Topics = temp.Topics
-	l.Address = temp.Address
+	return json.Marshal(temp)
+}
 
-	if temp.Data != "" {
-		temp.Data = temp.Data[2:]
+// ToGeth converts the log into a go-ethereum log, only the hint fields are populated
+func (l Log) ToGeth() *gethtypes.Log {
+	return &gethtypes.Log{
+		Address: l.Address,
+		Topics:  append([]common.Hash(nil), l.Topics...),
+		Data:    copyBytes(l.Data),
+	}
+}
 
-		decoded, err := hex.DecodeString(temp.Data)
-		if err != nil {
-			return err
-		}
+// FromGeth converts a go-ethereum log, block and transaction metadata is dropped
+func FromGeth(log *gethtypes.Log) Log {
+	return Log{
+		Address: log.Address,
+		Topics:  append([]common.Hash(nil), log.Topics...),
+		Data:    copyBytes(log.Data),
+	}
+}
 
-		l.Data = decoded
-	} else {
-		l.Data = nil
+// copyBytes copies b, keeping nil and empty apart since nil data was not revealed
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
 	}
 
-	return nil
+	return append([]byte{}, b...)
 }
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLog_UnmarshalJSON(t *testing.T) {
@@ -69,3 +71,138 @@ func TestLog_UnmarshalJSON_EmptyData(t *testing.T) {
 	assert.Equal(t, expectedTopics, log.Topics)
 	assert.Nil(t, log.Data)
 }
+
+func TestLog_MarshalJSON(t *testing.T) {
+	log := Log{
+		Address: common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678"),
+		Topics:  []common.Hash{common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890")},
+		Data:    []byte{0xde, 0xad, 0xbe, 0xef},
+	}
+
+	data, err := json.Marshal(log)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"address":"0x1234567890abcdef1234567890abcdef12345678","topics":["0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"],"data":"0xdeadbeef"}`, string(data))
+
+	log.Data = nil
+	data, err = json.Marshal(&log)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"address":"0x1234567890abcdef1234567890abcdef12345678","topics":["0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"]}`, string(data))
+}
+
+func TestLog_UnmarshalJSON_MalformedData(t *testing.T) {
+	for _, data := range []string{`"0"`, `"0xabc"`, `"0xzz"`, `12`} {
+		raw := []byte(`{"address":"0x1234567890abcdef1234567890abcdef12345678","data":` + data + `}`)
+
+		// json.Unmarshal decodes in DefaultDecodeMode and leaves the field empty
+		var log Log
+		assert.NoError(t, json.Unmarshal(raw, &log), data)
+		assert.Equal(t, common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678"), log.Address, data)
+		assert.Nil(t, log.Data, data)
+
+		_, err := (&Decoder{Mode: Strict}).Log("", raw)
+		var fieldErr *FieldError
+		assert.ErrorAs(t, err, &fieldErr, data)
+		assert.Equal(t, "data", fieldErr.Path, data)
+	}
+}
+
+func TestDecoder_Log(t *testing.T) {
+	raw := []byte(`{"address":"0x12","topics":["0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890","0x01"],"data":"0xzz"}`)
+
+	_, err := (&Decoder{Mode: Strict}).Log("logs[2]", raw)
+	var fieldErr *FieldError
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "logs[2].address", fieldErr.Path)
+
+	d := &Decoder{Mode: Lenient}
+	log, err := d.Log("logs[2]", raw)
+	assert.NoError(t, err)
+	assert.Len(t, log.Topics, 2)
+	assert.Nil(t, log.Data)
+	if assert.Len(t, d.Warnings, 3) {
+		assert.Equal(t, "logs[2].address", d.Warnings[0].Path)
+		assert.Equal(t, "logs[2].topics[1]", d.Warnings[1].Path)
+		assert.Equal(t, "logs[2].data", d.Warnings[2].Path)
+		assert.Equal(t, `"0xzz"`, d.Warnings[2].Value)
+	}
+}
+
+func TestLog_Geth(t *testing.T) {
+	log := Log{
+		Address: common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678"),
+		Topics:  []common.Hash{common.HexToHash("0xabcdef")},
+		Data:    []byte{0xde, 0xad},
+	}
+
+	gethLog := log.ToGeth()
+	assert.Equal(t, log.Address, gethLog.Address)
+	assert.Equal(t, log.Topics, gethLog.Topics)
+	assert.Equal(t, log.Data, gethLog.Data)
+	assert.True(t, gethtypes.BytesToBloom(gethtypes.LogsBloom([]*gethtypes.Log{gethLog})).Test(log.Address.Bytes()))
+
+	gethLog.Data[0] = 0x00
+	assert.Equal(t, byte(0xde), log.Data[0])
+
+	gethLog.BlockNumber = 10
+	assert.Equal(t, Log{Address: log.Address, Topics: log.Topics, Data: []byte{0x00, 0xad}}, FromGeth(gethLog))
+
+	// Redacted data stays redacted, revealed empty data stays revealed
+	assert.Nil(t, FromGeth(Log{}.ToGeth()).Data)
+	assert.NotNil(t, FromGeth(Log{Data: []byte{}}.ToGeth()).Data)
+}
+
+func FuzzLog_RoundTrip(f *testing.F) {
+	f.Add([]byte{0x12, 0x34}, []byte{0xab}, 1, []byte{0xde, 0xad, 0xbe, 0xef})
+	f.Add([]byte{}, []byte{}, 0, []byte{})
+	f.Add([]byte{0x01}, []byte{0x02, 0x03}, 4, []byte(nil))
+
+	f.Fuzz(func(t *testing.T, address, topic []byte, topics int, data []byte) {
+		log := Log{Address: common.BytesToAddress(address), Data: data}
+		for i := 0; i < topics%5; i++ {
+			log.Topics = append(log.Topics, common.BytesToHash(append(topic, byte(i))))
+		}
+
+		encoded, err := json.Marshal(log)
+		assert.NoError(t, err)
+
+		var decoded Log
+		assert.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, log.Address, decoded.Address)
+		assert.Equal(t, log.Topics, decoded.Topics)
+		assert.Equal(t, hex.EncodeToString(log.Data), hex.EncodeToString(decoded.Data))
+		assert.Equal(t, log.Data == nil, decoded.Data == nil)
+
+		reencoded, err := json.Marshal(decoded)
+		assert.NoError(t, err)
+		assert.Equal(t, string(encoded), string(reencoded))
+	})
+}
+
+func TestDecoder_Hex(t *testing.T) {
+	for _, mode := range []DecodeMode{Lenient, Strict} {
+		d := &Decoder{Mode: mode}
+
+		decoded, err := d.Hex("data", json.RawMessage(`"0xdeadbeef"`))
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, decoded)
+
+		decoded, err = d.Hex("data", json.RawMessage(`"0x"`))
+		require.NoError(t, err)
+		assert.NotNil(t, decoded)
+		assert.Empty(t, decoded)
+
+		decoded, err = d.Hex("data", json.RawMessage(`""`))
+		require.NoError(t, err)
+		assert.Nil(t, decoded)
+	}
+
+	// Unprefixed values are only accepted in lenient mode
+	d := &Decoder{Mode: Lenient}
+	decoded, err := d.Hex("data", json.RawMessage(`"deadbeef"`))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, decoded)
+	assert.Empty(t, d.Warnings)
+
+	_, err = (&Decoder{Mode: Strict}).Hex("data", json.RawMessage(`"deadbeef"`))
+	assert.Error(t, err)
+}